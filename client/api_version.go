@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Minimum CF API v3 versions required by features this client wraps
+var (
+	MinAPIVersionRollingDeployment     = Version{Major: 3, Minor: 57, Patch: 0}
+	MinAPIVersionDeployedRevisions     = Version{Major: 3, Minor: 77, Patch: 0}
+	MinAPIVersionLogRateLimit          = Version{Major: 3, Minor: 124, Patch: 0}
+	MinAPIVersionCanaryDeployment      = Version{Major: 3, Minor: 173, Patch: 0}
+	MinAPIVersionDeploymentMaxInFlight = Version{Major: 3, Minor: 173, Patch: 0}
+)
+
+// ErrUnsupportedAPIVersion is returned (wrapped in an UnsupportedAPIVersionError) when the CF API is too old
+// to support the requested feature
+var ErrUnsupportedAPIVersion = errors.New("unsupported CF API version")
+
+// UnsupportedAPIVersionError is returned before making a request the CF API version is known not to support
+type UnsupportedAPIVersionError struct {
+	Feature        string
+	MinimumVersion Version
+	ActualVersion  Version
+}
+
+func (e UnsupportedAPIVersionError) Error() string {
+	return fmt.Sprintf("%s: %s requires CF API version %s or later, but the API version is %s",
+		ErrUnsupportedAPIVersion, e.Feature, e.MinimumVersion, e.ActualVersion)
+}
+
+// Is allows errors.Is(err, ErrUnsupportedAPIVersion) to match
+func (e UnsupportedAPIVersionError) Is(target error) bool {
+	return target == ErrUnsupportedAPIVersion
+}
+
+// Version is a semantic version of the CF API
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion parses a semantic version string like 3.127.0, any pre-release or build suffix is ignored
+func ParseVersion(version string) (Version, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("expected a semantic version, but got '%s'", version)
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("expected a semantic version, but got '%s'", version)
+		}
+		nums[i] = n
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+// Compare returns -1 if v is less than other, 0 if they're equal, and 1 if v is greater than other
+func (v Version) Compare(other Version) int {
+	switch {
+	case v.Major != other.Major:
+		return compareInt(v.Major, other.Major)
+	case v.Minor != other.Minor:
+		return compareInt(v.Minor, other.Minor)
+	default:
+		return compareInt(v.Patch, other.Patch)
+	}
+}
+
+// AtLeast returns true if v is greater than or equal to the minimum version
+func (v Version) AtLeast(minimum Version) bool {
+	return v.Compare(minimum) >= 0
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// APIVersion returns the CF API v3 version
+//
+// The version is queried from the API root on first use and then cached for the lifetime of the client.
+func (c *Client) APIVersion(ctx context.Context) (Version, error) {
+	c.apiVersionMutex.Lock()
+	defer c.apiVersionMutex.Unlock()
+	if c.apiVersion != nil {
+		return *c.apiVersion, nil
+	}
+
+	root, err := c.Root.Get(ctx)
+	if err != nil {
+		return Version{}, fmt.Errorf("error getting the CF API version: %w", err)
+	}
+	v, err := ParseVersion(root.Links.CloudControllerV3.Meta.Version)
+	if err != nil {
+		return Version{}, fmt.Errorf("error parsing the CF API version: %w", err)
+	}
+	c.apiVersion = &v
+	return v, nil
+}
+
+// requireAPIVersion returns an UnsupportedAPIVersionError if the CF API version is older than the minimum
+// version required by the feature
+func (c *Client) requireAPIVersion(ctx context.Context, feature string, minimum Version) error {
+	v, err := c.APIVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if !v.AtLeast(minimum) {
+		return UnsupportedAPIVersionError{
+			Feature:        feature,
+			MinimumVersion: minimum,
+			ActualVersion:  v,
+		}
+	}
+	return nil
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
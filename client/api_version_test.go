@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("3.127.0")
+	require.NoError(t, err)
+	require.Equal(t, Version{Major: 3, Minor: 127}, v)
+	require.Equal(t, "3.127.0", v.String())
+
+	v, err = ParseVersion("v3.90.1-rc.1")
+	require.NoError(t, err)
+	require.Equal(t, Version{Major: 3, Minor: 90, Patch: 1}, v)
+
+	v, err = ParseVersion("3.90")
+	require.NoError(t, err)
+	require.Equal(t, Version{Major: 3, Minor: 90}, v)
+
+	_, err = ParseVersion("")
+	require.Error(t, err)
+	_, err = ParseVersion("3.x.0")
+	require.Error(t, err)
+	_, err = ParseVersion("3.1.2.4")
+	require.Error(t, err)
+}
+
+func TestVersionCompare(t *testing.T) {
+	v := Version{Major: 3, Minor: 124}
+	require.Equal(t, 0, v.Compare(Version{Major: 3, Minor: 124}))
+	require.Equal(t, 1, v.Compare(Version{Major: 3, Minor: 99, Patch: 9}))
+	require.Equal(t, -1, v.Compare(Version{Major: 3, Minor: 124, Patch: 1}))
+	require.Equal(t, -1, v.Compare(Version{Major: 4}))
+	require.True(t, v.AtLeast(MinAPIVersionLogRateLimit))
+	require.False(t, v.AtLeast(MinAPIVersionCanaryDeployment))
+}
+
+func TestAPIVersion(t *testing.T) {
+	tests := []RouteTest{
+		{
+			Description: "Get API version",
+			Action: func(c *Client, t *testing.T) (any, error) {
+				v, err := c.APIVersion(context.Background())
+				require.NoError(t, err)
+				require.Equal(t, Version{Major: 3, Minor: 180}, v)
+				return nil, nil
+			},
+		},
+		{
+			Description: "Scale process log rate limit on an unsupported API version",
+			Action: func(c *Client, t *testing.T) (any, error) {
+				c.apiVersion = &Version{Major: 3, Minor: 90}
+				r := resource.NewProcessScale().WithLogRateLimitInBytesPerSecond(1024)
+				_, err := c.Processes.Scale(context.Background(), "ec4ff362-60c5-47a0-8246-2a134537c606", r)
+				require.ErrorIs(t, err, ErrUnsupportedAPIVersion)
+
+				var versionErr UnsupportedAPIVersionError
+				require.True(t, errors.As(err, &versionErr))
+				require.Equal(t, MinAPIVersionLogRateLimit, versionErr.MinimumVersion)
+				require.Equal(t, Version{Major: 3, Minor: 90}, versionErr.ActualVersion)
+				return nil, nil
+			},
+		},
+		{
+			Description: "Create canary deployment on an unsupported API version",
+			Action: func(c *Client, t *testing.T) (any, error) {
+				c.apiVersion = &Version{Major: 3, Minor: 160}
+				r := resource.NewDeploymentCreate("305cea31-5a44-45ca-b51b-e89c7a8ef8b2")
+				r.Strategy = "canary"
+				_, err := c.Deployments.Create(context.Background(), r)
+				require.ErrorIs(t, err, ErrUnsupportedAPIVersion)
+				return nil, nil
+			},
+		},
+		{
+			Description: "List deployed revisions on an unsupported API version",
+			Action: func(c *Client, t *testing.T) (any, error) {
+				c.apiVersion = &Version{Major: 3, Minor: 60}
+				_, _, err := c.Revisions.ListForAppDeployed(context.Background(), "487d2a80-3769-4ad8-8ef5-a02c363d017b", nil)
+				require.ErrorIs(t, err, ErrUnsupportedAPIVersion)
+				return nil, nil
+			},
+		},
+	}
+	ExecuteTests(tests, t)
+}
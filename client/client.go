@@ -17,6 +17,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 )
 
 // Client used to communicate with Cloud Foundry
@@ -36,6 +37,7 @@ type Client struct {
 	IsolationSegments         *IsolationSegmentClient
 	Jobs                      *JobClient
 	Manifests                 *ManifestClient
+	Info                      *InfoClient
 	Organizations             *OrganizationClient
 	OrganizationQuotas        *OrganizationQuotaClient
 	Packages                  *PackageClient
@@ -67,32 +69,65 @@ type Client struct {
 
 	unauthenticatedClientProvider *http.UnauthenticatedClientProvider
 	unauthenticatedHTTPExecutor   *http.Executor
+	uaaClientProvider             *http.UnauthenticatedClientProvider
 	authenticatedHTTPExecutor     *http.Executor
 	authenticatedClientProvider   *http.OAuthSessionManager
+
+	apiVersion      *Version
+	apiVersionMutex sync.Mutex
 }
 
 type commonClient struct {
 	client *Client
 }
 
+// formField is a non-file multipart form field
+type formField struct {
+	name  string
+	value string
+}
+
 // New returns a new CF client
-func New(config *config.Config) (*Client, error) {
-	// construct an unauthenticated root client
-	unauthenticatedClientProvider := http.NewUnauthenticatedClientProvider(config.HTTPClient())
-	unauthenticatedHTTPExecutor := http.NewExecutor(unauthenticatedClientProvider, config.APIEndpointURL, config.UserAgent)
-	rootClient := NewRootClient(unauthenticatedHTTPExecutor)
-	err := authServiceDiscovery(context.Background(), config, rootClient)
+//
+// Unless the UAA and login endpoints are already configured, the API root is queried to discover them which
+// fails if the API is unreachable. Use NewLazy to defer all network calls until the first request.
+func New(cfg *config.Config) (*Client, error) {
+	client, err := NewLazy(cfg)
 	if err != nil {
 		return nil, err
 	}
+	err = authServiceDiscovery(context.Background(), cfg, client.Root)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// NewLazy returns a new CF client without making any network calls
+//
+// API root discovery and token acquisition happen once on the first request that needs them, and are
+// cached for all subsequent requests. Use Ping to validate connectivity and credentials on demand.
+func NewLazy(cfg *config.Config) (*Client, error) {
+	if cfg == nil {
+		return nil, errors.New("expected a non-nil config")
+	}
+
+	// construct an unauthenticated root client
+	unauthenticatedClientProvider := http.NewUnauthenticatedClientProvider(cfg.EndpointHTTPClient(config.EndpointAPI))
+	unauthenticatedHTTPExecutor := http.NewExecutor(unauthenticatedClientProvider, cfg.APIEndpointURL, cfg.UserAgent)
+	rootClient := NewRootClient(unauthenticatedHTTPExecutor)
 
 	// create the client instance
-	authenticatedClientProvider := http.NewOAuthSessionManager(config)
-	authenticatedHTTPExecutor := http.NewExecutor(authenticatedClientProvider, config.APIEndpointURL, config.UserAgent)
+	authenticatedClientProvider := http.NewOAuthSessionManager(cfg).
+		WithEndpointDiscovery(func(ctx context.Context) error {
+			return authServiceDiscovery(ctx, cfg, rootClient)
+		})
+	authenticatedHTTPExecutor := http.NewExecutor(authenticatedClientProvider, cfg.APIEndpointURL, cfg.UserAgent)
 	client := &Client{
-		config:                        config,
+		config:                        cfg,
 		unauthenticatedHTTPExecutor:   unauthenticatedHTTPExecutor,
 		unauthenticatedClientProvider: unauthenticatedClientProvider,
+		uaaClientProvider:             http.NewUnauthenticatedClientProvider(cfg.EndpointHTTPClient(config.EndpointUAA)),
 		authenticatedHTTPExecutor:     authenticatedHTTPExecutor,
 		authenticatedClientProvider:   authenticatedClientProvider,
 	}
@@ -100,6 +135,7 @@ func New(config *config.Config) (*Client, error) {
 	// populate sub-clients
 	client.common.client = client
 	client.Root = rootClient
+	client.Info = NewInfoClient(unauthenticatedHTTPExecutor, authenticatedHTTPExecutor)
 	client.Admin = (*AdminClient)(&client.common)
 	client.Applications = (*AppClient)(&client.common)
 	client.AppFeatures = (*AppFeatureClient)(&client.common)
@@ -151,6 +187,29 @@ func (c *Client) AccessToken(ignoredCtx context.Context) (string, error) {
 	return token, nil
 }
 
+// Ping validates connectivity to the CF API and the configured credentials
+//
+// This queries the API root, authenticates if not already authenticated, and then makes a lightweight
+// authenticated request to ensure the access token is accepted by the API.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Root.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("error connecting to the CF API %s: %w", c.config.APIEndpointURL, err)
+	}
+	err = c.authenticatedClientProvider.Authenticate(ctx)
+	if err != nil {
+		return fmt.Errorf("error authenticating to the CF API %s: %w", c.config.APIEndpointURL, err)
+	}
+
+	opts := NewOrganizationListOptions()
+	opts.PerPage = 1
+	_, _, err = c.Organizations.List(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("error making an authenticated request to the CF API %s: %w", c.config.APIEndpointURL, err)
+	}
+	return nil
+}
+
 // SSHCode generates an SSH code that can be used by generic SSH clients to SSH into app instances
 func (c *Client) SSHCode(ctx context.Context) (string, error) {
 	// need this to grab the SSH client id, should probably be cached in config
@@ -172,7 +231,7 @@ func (c *Client) SSHCode(ctx context.Context) (string, error) {
 		WithHeader("Authorization", fmt.Sprintf("bearer %s", token)).
 		WithFollowRedirects(false)
 
-	uaaHTTPExecutor := http.NewExecutor(c.unauthenticatedClientProvider, c.config.UAAEndpointURL, c.config.UserAgent)
+	uaaHTTPExecutor := http.NewExecutor(c.uaaClientProvider, c.config.UAAEndpointURL, c.config.UserAgent)
 	resp, err := uaaHTTPExecutor.ExecuteRequest(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to get one-time code: %w", err)
@@ -308,6 +367,12 @@ func (c *Client) post(ctx context.Context, path string, params, result any) (str
 // struct to unmarshall the result body. If the resource returns an async job ID in the Location
 // header then the job GUID is returned which the caller can reference via the job endpoint.
 func (c *Client) postFileUpload(ctx context.Context, path, fieldName, fileName string, fileToUpload io.Reader, result any) (string, error) {
+	return c.postMultipartUpload(ctx, path, nil, fieldName, fileName, fileToUpload, result)
+}
+
+// postMultipartUpload does an HTTP POST of a multipart form containing the specified form fields followed
+// by the file, if not nil, and handles the result the same as postFileUpload
+func (c *Client) postMultipartUpload(ctx context.Context, path string, fields []formField, fieldName, fileName string, fileToUpload io.Reader, result any) (string, error) {
 	if !check.IsNil(result) && !check.IsPointer(result) {
 		return "", errors.New("expected result to be a pointer type, or nil")
 	}
@@ -319,13 +384,21 @@ func (c *Client) postFileUpload(ctx context.Context, path, fieldName, fileName s
 	defer ios.CleanupTempFile(requestFile)
 
 	formWriter := multipart.NewWriter(requestFile)
-	part, err := formWriter.CreateFormFile(fieldName, fileName)
-	if err != nil {
-		return "", fmt.Errorf("error uploading file to %s: %w", path, err)
+	for _, f := range fields {
+		err = formWriter.WriteField(f.name, f.value)
+		if err != nil {
+			return "", fmt.Errorf("error uploading file to %s, failed to write %s form field: %w", path, f.name, err)
+		}
 	}
-	_, err = io.Copy(part, fileToUpload)
-	if err != nil {
-		return "", fmt.Errorf("error uploading file to %s, failed on copy: %w", path, err)
+	if fileToUpload != nil {
+		part, err := formWriter.CreateFormFile(fieldName, fileName)
+		if err != nil {
+			return "", fmt.Errorf("error uploading file to %s: %w", path, err)
+		}
+		_, err = io.Copy(part, fileToUpload)
+		if err != nil {
+			return "", fmt.Errorf("error uploading file to %s, failed on copy: %w", path, err)
+		}
 	}
 	err = formWriter.Close()
 	if err != nil {
@@ -358,6 +431,11 @@ func (c *Client) postFileUpload(ctx context.Context, path, fieldName, fileName s
 
 // decodeError attempts to unmarshall the response body as a CF error
 func (c *Client) decodeError(resp *http2.Response) error {
+	return decodeError(resp)
+}
+
+// decodeError attempts to unmarshall the response body as a CF error
+func decodeError(resp *http2.Response) error {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return CloudFoundryHTTPError{
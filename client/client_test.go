@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"github.com/cloudfoundry-community/go-cfclient/v3/config"
+	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestNewLazy(t *testing.T) {
+	// no network calls are made when creating the client, even when the API is unreachable
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+	c, err := config.NewToken(unreachable.URL, "foobar")
+	require.NoError(t, err)
+	cl, err := NewLazy(c)
+	require.NoError(t, err)
+	require.NotNil(t, cl)
+	require.Error(t, cl.Ping(context.Background()))
+
+	_, err = New(c)
+	require.Error(t, err)
+
+	_, err = NewLazy(nil)
+	require.Error(t, err)
+}
+
+func TestNewLazyDiscoversOnFirstRequest(t *testing.T) {
+	g := testutil.NewObjectJSONGenerator(1)
+	org := g.Organization().JSON
+	serverURL := testutil.SetupMultiple([]testutil.MockRoute{
+		{
+			Method:   "GET",
+			Endpoint: "/v3/organizations",
+			Output:   []string{g.SinglePaged(org)[0], g.SinglePaged(org)[0], g.SinglePaged(org)[0], g.SinglePaged(org)[0]},
+			Status:   http.StatusOK,
+		},
+	}, t)
+	defer testutil.Teardown()
+
+	c, err := config.NewClientSecret(serverURL, "opsman", "secret")
+	require.NoError(t, err)
+	cl, err := NewLazy(c)
+	require.NoError(t, err)
+	require.Empty(t, c.UAAEndpointURL)
+
+	// concurrent first requests share a single discovery and auth context
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, errs[i] = cl.Organizations.List(context.Background(), nil)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	require.NotEmpty(t, c.UAAEndpointURL)
+	require.NotEmpty(t, c.LoginEndpointURL)
+
+	require.NoError(t, cl.Ping(context.Background()))
+}
+
+func TestPingCanceledContextDoesNotBreakTokenRefresh(t *testing.T) {
+	// tokens expire immediately so every request refreshes the token
+	testutil.SetupFakeUAAServer(1)
+	g := testutil.NewObjectJSONGenerator(1)
+	org := g.Organization().JSON
+	serverURL := testutil.SetupMultiple([]testutil.MockRoute{
+		{
+			Method:   "GET",
+			Endpoint: "/v3/organizations",
+			Output:   []string{g.SinglePaged(org)[0], g.SinglePaged(org)[0]},
+			Status:   http.StatusOK,
+		},
+	}, t)
+	defer testutil.Teardown()
+
+	c, err := config.NewClientSecret(serverURL, "opsman", "secret")
+	require.NoError(t, err)
+	cl, err := NewLazy(c)
+	require.NoError(t, err)
+
+	// the health check's ctx initializes auth and is then canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, cl.Ping(ctx))
+	cancel()
+
+	_, _, err = cl.Organizations.List(context.Background(), nil)
+	require.NoError(t, err)
+}
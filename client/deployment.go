@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/cloudfoundry-community/go-cfclient/v3/internal/path"
 	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
 	"net/url"
@@ -37,12 +38,43 @@ func (c *DeploymentClient) Cancel(ctx context.Context, guid string) error {
 	return err
 }
 
+// Continue a paused canary deployment to its next step, or to completion if it's the last step
+func (c *DeploymentClient) Continue(ctx context.Context, guid string) (*resource.Deployment, error) {
+	var d resource.Deployment
+	_, err := c.client.post(ctx, path.Format("/v3/deployments/%s/actions/continue", guid), nil, &d)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
 // Create a new deployment
 func (c *DeploymentClient) Create(ctx context.Context, r *resource.DeploymentCreate) (*resource.Deployment, error) {
 	// validate the params
 	if r.Droplet != nil && r.Revision != nil {
 		return nil, errors.New("droplet and revision cannot both be set")
 	}
+	if r.Options != nil && r.Options.Canary != nil && r.Strategy != resource.DeploymentStrategyCanary {
+		return nil, errors.New("canary options are only valid with the canary strategy")
+	}
+	switch r.Strategy {
+	case resource.DeploymentStrategyRolling:
+		err := c.client.requireAPIVersion(ctx, "rolling deployments", MinAPIVersionRollingDeployment)
+		if err != nil {
+			return nil, err
+		}
+	case resource.DeploymentStrategyCanary:
+		err := c.client.requireAPIVersion(ctx, "canary deployments", MinAPIVersionCanaryDeployment)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.Options != nil && r.Options.MaxInFlight != nil {
+		err := c.client.requireAPIVersion(ctx, "deployment max in flight", MinAPIVersionDeploymentMaxInFlight)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	var d resource.Deployment
 	_, err := c.client.post(ctx, "/v3/deployments", r, &d)
@@ -93,6 +125,24 @@ func (c *DeploymentClient) ListAll(ctx context.Context, opts *DeploymentListOpti
 	})
 }
 
+// PollDeployed waits until the deployment is finalized with the DEPLOYED reason, is finalized with any other
+// reason like CANCELED, or times out
+//
+// This doesn't return when a canary deployment pauses, use PollPaused for that.
+func (c *DeploymentClient) PollDeployed(ctx context.Context, guid string, opts *PollingOptions) error {
+	return c.pollForStatus(ctx, guid, resource.DeploymentStatus.IsDeployed, opts)
+}
+
+// PollPaused waits until the canary deployment is paused, the deployment is finalized, or times out
+//
+// A canary deployment that's finalized with the DEPLOYED reason, e.g. because it was continued by someone else
+// or had no pause steps, is also a success. Finalized with any other reason like CANCELED is an error.
+func (c *DeploymentClient) PollPaused(ctx context.Context, guid string, opts *PollingOptions) error {
+	return c.pollForStatus(ctx, guid, func(status resource.DeploymentStatus) bool {
+		return status.IsPaused() || status.IsDeployed()
+	}, opts)
+}
+
 // Single returns a single deployment matching the options or an error if not exactly 1 match
 func (c *DeploymentClient) Single(ctx context.Context, opts *DeploymentListOptions) (*resource.Deployment, error) {
 	return Single[*DeploymentListOptions, *resource.Deployment](opts, func(opts *DeploymentListOptions) ([]*resource.Deployment, *Pager, error) {
@@ -109,3 +159,36 @@ func (c *DeploymentClient) Update(ctx context.Context, guid string, r *resource.
 	}
 	return &d, nil
 }
+
+// pollForStatus waits until the deployment status matches, or the deployment is finalized and doesn't match
+func (c *DeploymentClient) pollForStatus(ctx context.Context, guid string, isDone func(resource.DeploymentStatus) bool, opts *PollingOptions) error {
+	if opts == nil {
+		opts = NewPollingOptions()
+	}
+
+	const successState = "DONE"
+	var status resource.DeploymentStatus
+	err := pollForStateOrTimeout(ctx, func() (string, error) {
+		d, err := c.Get(ctx, guid)
+		if err != nil {
+			return "", err
+		}
+		status = d.Status
+		switch {
+		case isDone(status):
+			return successState, nil
+		case !status.IsActive():
+			return opts.FailedState, nil
+		}
+		return string(status.Reason), nil
+	}, successState, opts)
+
+	if err == AsyncProcessFailedError {
+		if status.Details.Error != "" {
+			return fmt.Errorf("deployment %s finalized with reason %s: %s: %w",
+				guid, status.Reason, status.Details.Error, err)
+		}
+		return fmt.Errorf("deployment %s finalized with reason %s: %w", guid, status.Reason, err)
+	}
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestDeployments(t *testing.T) {
@@ -15,6 +16,13 @@ func TestDeployments(t *testing.T) {
 	deployment2 := g.Deployment().JSON
 	deployment3 := g.Deployment().JSON
 	deployment4 := g.Deployment().JSON
+	pausedDeployment := g.DeploymentWithStatus("ACTIVE", "PAUSED").JSON
+	deployedDeployment := g.DeploymentWithStatus("FINALIZED", "DEPLOYED").JSON
+	canceledDeployment := g.DeploymentWithStatus("FINALIZED", "CANCELED").JSON
+
+	pollingOpts := NewPollingOptions()
+	pollingOpts.CheckInterval = time.Millisecond
+	pollingOpts.Timeout = time.Second
 
 	tests := []RouteTest{
 		{
@@ -69,6 +77,117 @@ func TestDeployments(t *testing.T) {
 				return nil, nil
 			},
 		},
+		{
+			Description: "Create canary deployment",
+			Route: testutil.MockRoute{
+				Method:   "POST",
+				Endpoint: "/v3/deployments",
+				Output:   g.Single(deployment),
+				Status:   http.StatusCreated,
+				PostForm: `{
+					"relationships":{"app":{"data":{"guid":"305cea31-5a44-45ca-b51b-e89c7a8ef8b2"}}},
+					"droplet": {"guid": "c2941033-4575-486d-bf2c-3ae49e8b4ca1"},
+					"strategy": "canary",
+					"options": {"max_in_flight": 2, "canary": {"steps": [{"instance_weight": 20}, {"instance_weight": 80}]}}
+				}`,
+			},
+			Expected: deployment,
+			Action: func(c *Client, t *testing.T) (any, error) {
+				maxInFlight := 2
+				r := resource.NewDeploymentCreate("305cea31-5a44-45ca-b51b-e89c7a8ef8b2")
+				r.Droplet = &resource.Relationship{
+					GUID: "c2941033-4575-486d-bf2c-3ae49e8b4ca1",
+				}
+				r.Strategy = resource.DeploymentStrategyCanary
+				r.Options = &resource.DeploymentOptions{
+					MaxInFlight: &maxInFlight,
+					Canary: &resource.DeploymentCanaryOptions{
+						Steps: []resource.DeploymentCanaryStep{
+							{InstanceWeight: 20},
+							{InstanceWeight: 80},
+						},
+					},
+				}
+				return c.Deployments.Create(context.Background(), r)
+			},
+		},
+		{
+			Description: "Create rolling deployment with canary options",
+			Action: func(c *Client, t *testing.T) (any, error) {
+				r := resource.NewDeploymentCreate("305cea31-5a44-45ca-b51b-e89c7a8ef8b2")
+				r.Strategy = resource.DeploymentStrategyRolling
+				r.Options = &resource.DeploymentOptions{
+					Canary: &resource.DeploymentCanaryOptions{},
+				}
+				_, err := c.Deployments.Create(context.Background(), r)
+				require.ErrorContains(t, err, "canary options are only valid with the canary strategy")
+				return nil, nil
+			},
+		},
+		{
+			Description: "Continue deployment",
+			Route: testutil.MockRoute{
+				Method:   "POST",
+				Endpoint: "/v3/deployments/2b56dc7b-2a14-49ea-be29-ca182b14a998/actions/continue",
+				Output:   g.Single(deployment),
+				Status:   http.StatusOK,
+			},
+			Expected: deployment,
+			Action: func(c *Client, t *testing.T) (any, error) {
+				return c.Deployments.Continue(context.Background(), "2b56dc7b-2a14-49ea-be29-ca182b14a998")
+			},
+		},
+		{
+			Description: "Poll deployment until deployed",
+			Route: testutil.MockRoute{
+				Method:   "GET",
+				Endpoint: "/v3/deployments/2b56dc7b-2a14-49ea-be29-ca182b14a998",
+				Output:   []string{deployment, pausedDeployment, deployedDeployment},
+				Status:   http.StatusOK,
+			},
+			Action: func(c *Client, t *testing.T) (any, error) {
+				return nil, c.Deployments.PollDeployed(context.Background(), "2b56dc7b-2a14-49ea-be29-ca182b14a998", pollingOpts)
+			},
+		},
+		{
+			Description: "Poll deployment until paused",
+			Route: testutil.MockRoute{
+				Method:   "GET",
+				Endpoint: "/v3/deployments/2b56dc7b-2a14-49ea-be29-ca182b14a998",
+				Output:   []string{deployment, pausedDeployment},
+				Status:   http.StatusOK,
+			},
+			Action: func(c *Client, t *testing.T) (any, error) {
+				return nil, c.Deployments.PollPaused(context.Background(), "2b56dc7b-2a14-49ea-be29-ca182b14a998", pollingOpts)
+			},
+		},
+		{
+			Description: "Poll canary deployment until paused that finishes deploying",
+			Route: testutil.MockRoute{
+				Method:   "GET",
+				Endpoint: "/v3/deployments/2b56dc7b-2a14-49ea-be29-ca182b14a998",
+				Output:   []string{deployment, deployedDeployment},
+				Status:   http.StatusOK,
+			},
+			Action: func(c *Client, t *testing.T) (any, error) {
+				return nil, c.Deployments.PollPaused(context.Background(), "2b56dc7b-2a14-49ea-be29-ca182b14a998", pollingOpts)
+			},
+		},
+		{
+			Description: "Poll canceled deployment",
+			Route: testutil.MockRoute{
+				Method:   "GET",
+				Endpoint: "/v3/deployments/2b56dc7b-2a14-49ea-be29-ca182b14a998",
+				Output:   []string{deployment, canceledDeployment},
+				Status:   http.StatusOK,
+			},
+			Action: func(c *Client, t *testing.T) (any, error) {
+				err := c.Deployments.PollDeployed(context.Background(), "2b56dc7b-2a14-49ea-be29-ca182b14a998", pollingOpts)
+				require.ErrorIs(t, err, AsyncProcessFailedError)
+				require.ErrorContains(t, err, "finalized with reason CANCELED")
+				return nil, nil
+			},
+		},
 		{
 			Description: "Cancel deployment",
 			Route: testutil.MockRoute{
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/internal/http"
+	"github.com/cloudfoundry-community/go-cfclient/v3/internal/ios"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	http2 "net/http"
+)
+
+// InfoClient queries the platform info endpoints /v3/info
+type InfoClient struct {
+	unauthenticatedHTTPExecutor *http.Executor
+	authenticatedHTTPExecutor   *http.Executor
+}
+
+// NewInfoClient creates an initialized info client
+//
+// The platform info doesn't require authentication, however the usage summary does so requires
+// an authenticated executor.
+func NewInfoClient(unauthenticatedHTTPExecutor, authenticatedHTTPExecutor *http.Executor) *InfoClient {
+	return &InfoClient{
+		unauthenticatedHTTPExecutor: unauthenticatedHTTPExecutor,
+		authenticatedHTTPExecutor:   authenticatedHTTPExecutor,
+	}
+}
+
+// Get queries the platform info /v3/info
+//
+// This includes the foundation name, build, and the CLI version constraints.
+func (c *InfoClient) Get(ctx context.Context) (*resource.Info, error) {
+	var info resource.Info
+	err := c.get(ctx, c.unauthenticatedHTTPExecutor, "/v3/info", &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// GetUsageSummary queries the platform-wide usage summary /v3/info/usage_summary
+//
+// This requires an admin, admin read-only or global auditor user.
+func (c *InfoClient) GetUsageSummary(ctx context.Context) (*resource.InfoUsageSummary, error) {
+	if c.authenticatedHTTPExecutor == nil {
+		return nil, errors.New("getting the platform usage summary requires an authenticated client")
+	}
+	var summary resource.InfoUsageSummary
+	err := c.get(ctx, c.authenticatedHTTPExecutor, "/v3/info/usage_summary", &summary)
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func (c *InfoClient) get(ctx context.Context, httpExecutor *http.Executor, path string, result any) error {
+	req := http.NewRequest(ctx, http2.MethodGet, path)
+	res, err := httpExecutor.ExecuteRequest(req)
+	if err != nil {
+		return fmt.Errorf("error getting %s: %w", path, err)
+	}
+	defer ios.CloseReaderIgnoreError(res.Body)
+	if res.StatusCode != http2.StatusOK {
+		return decodeError(res)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(result)
+	if err != nil {
+		return fmt.Errorf("error decoding %s get response JSON: %w", path, err)
+	}
+	return nil
+}
@@ -20,7 +20,7 @@ func (c *JobClient) Get(ctx context.Context, guid string) (*resource.Job, error)
 
 // PollComplete waits until the job completes, fails, or times out
 func (c *JobClient) PollComplete(ctx context.Context, jobGUID string, opts *PollingOptions) error {
-	err := PollForStateOrTimeout(func() (string, error) {
+	err := pollForStateOrTimeout(ctx, func() (string, error) {
 		job, err := c.Get(ctx, jobGUID)
 		if job != nil {
 			return string(job.State), err
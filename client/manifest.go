@@ -2,9 +2,11 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/cloudfoundry-community/go-cfclient/v3/internal/http"
 	"github.com/cloudfoundry-community/go-cfclient/v3/internal/path"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
 	"io"
 	http2 "net/http"
 	"strings"
@@ -65,3 +67,32 @@ func (c *ManifestClient) ApplyManifest(ctx context.Context, spaceGUID string, ma
 	}
 	return jobGUID, nil
 }
+
+// Diff returns the differences between the specified manifest and the current state of the apps in the space
+//
+// This doesn't change anything, it's useful to show what ApplyManifest would change before applying it. The
+// differences are returned as JSON-Patch style add, remove and replace operations.
+func (c *ManifestClient) Diff(ctx context.Context, spaceGUID string, manifest string) (*resource.ManifestDiff, error) {
+	p := path.Format("/v3/spaces/%s/manifest_diff", spaceGUID)
+	req := http.NewRequest(ctx, http2.MethodPost, p).
+		WithContentType("application/x-yaml").
+		WithBody(strings.NewReader(manifest))
+
+	resp, err := c.client.authenticatedHTTPExecutor.ExecuteRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s: %w", p, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http2.StatusCreated && resp.StatusCode != http2.StatusOK {
+		return nil, c.client.decodeError(resp)
+	}
+
+	var diff resource.ManifestDiff
+	err = json.NewDecoder(resp.Body).Decode(&diff)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s response JSON: %w", p, err)
+	}
+	return &diff, nil
+}
@@ -11,6 +11,7 @@ import (
 func TestManifests(t *testing.T) {
 	g := testutil.NewObjectJSONGenerator(1)
 	manifest := g.Manifest().JSON
+	manifestDiff := g.ManifestDiff().JSON
 
 	tests := []RouteTest{
 		{
@@ -27,6 +28,18 @@ func TestManifests(t *testing.T) {
 				return nil, nil
 			},
 		},
+		{
+			Description: "Diff space manifest",
+			Route: testutil.MockRoute{
+				Method:   "POST",
+				Endpoint: "/v3/spaces/9f3d2ce1-d1e5-4b5d-ae37-d2a8f1e3ac2d/manifest_diff",
+				Output:   g.Single(manifestDiff),
+				Status:   http.StatusCreated},
+			Expected: manifestDiff,
+			Action: func(c *Client, t *testing.T) (any, error) {
+				return c.Manifests.Diff(context.Background(), "9f3d2ce1-d1e5-4b5d-ae37-d2a8f1e3ac2d", manifest)
+			},
+		},
 	}
 	ExecuteTests(tests, t)
 }
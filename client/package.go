@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/cloudfoundry-community/go-cfclient/v3/internal/http"
@@ -211,3 +212,22 @@ func (c *PackageClient) Upload(ctx context.Context, guid string, zipFile io.Read
 	_, err := c.client.postFileUpload(ctx, p, "bits", "package.zip", zipFile, &pkg)
 	return &pkg, err
 }
+
+// UploadWithResources uploads an app's zip file contents along with the resources previously matched by the
+// ResourceMatchClient, which Cloud Foundry copies from its resource cache instead. The zip file should only
+// contain the unmatched files, and may be nil if every file was matched.
+func (c *PackageClient) UploadWithResources(ctx context.Context, guid string, zipFile io.Reader, resources []resource.ResourceMatch) (*resource.Package, error) {
+	if resources == nil {
+		resources = []resource.ResourceMatch{}
+	}
+	b, err := json.Marshal(resources)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling package resources: %w", err)
+	}
+	fields := []formField{{name: "resources", value: string(b)}}
+
+	p := path.Format("/v3/packages/%s/upload", guid)
+	var pkg resource.Package
+	_, err = c.client.postMultipartUpload(ctx, p, fields, "bits", "package.zip", zipFile, &pkg)
+	return &pkg, err
+}
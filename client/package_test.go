@@ -216,6 +216,54 @@ func TestPackages(t *testing.T) {
 				return c.Packages.Upload(context.Background(), "8d1f1d2e-08b1-4a10-a8df-471a1418cb8b", zipFile)
 			},
 		},
+		{
+			Description: "Upload package with matched resources",
+			Route: testutil.MockRoute{
+				Method:   "POST-FILE",
+				Endpoint: "/v3/packages/8d1f1d2e-08b1-4a10-a8df-471a1418cb8b/upload",
+				Output:   g.Single(pkg),
+				Status:   http.StatusOK,
+				MultipartFields: map[string]string{
+					"resources": `[{"checksum":{"value":"002d760bea1be268e27077412e11a320d0f164d3"},"size_in_bytes":36,"path":"C:\\path\\to\\file","mode":"645"}]`,
+				},
+				MultipartFiles: []string{"bits"},
+			},
+			Expected: pkg,
+			Action: func(c *Client, t *testing.T) (any, error) {
+				zipFile := strings.NewReader("package")
+				return c.Packages.UploadWithResources(context.Background(), "8d1f1d2e-08b1-4a10-a8df-471a1418cb8b", zipFile, []resource.ResourceMatch{
+					{
+						Checksum:    resource.ResourceMatchChecksum{Value: "002d760bea1be268e27077412e11a320d0f164d3"},
+						SizeInBytes: 36,
+						Path:        "C:\\path\\to\\file",
+						Mode:        "645",
+					},
+				})
+			},
+		},
+		{
+			Description: "Upload package with only matched resources",
+			Route: testutil.MockRoute{
+				Method:   "POST-FILE",
+				Endpoint: "/v3/packages/8d1f1d2e-08b1-4a10-a8df-471a1418cb8b/upload",
+				Output:   g.Single(pkg),
+				Status:   http.StatusOK,
+				MultipartFields: map[string]string{
+					"resources": `[{"checksum":{"value":"a9993e364706816aba3e25717850c26c9cd0d89d"},"size_in_bytes":3,"path":"app.js","mode":"644"}]`,
+				},
+			},
+			Expected: pkg,
+			Action: func(c *Client, t *testing.T) (any, error) {
+				return c.Packages.UploadWithResources(context.Background(), "8d1f1d2e-08b1-4a10-a8df-471a1418cb8b", nil, []resource.ResourceMatch{
+					{
+						Checksum:    resource.ResourceMatchChecksum{Value: "a9993e364706816aba3e25717850c26c9cd0d89d"},
+						SizeInBytes: 3,
+						Path:        "app.js",
+						Mode:        "644",
+					},
+				})
+			},
+		},
 	}
 	ExecuteTests(tests, t)
 }
@@ -1,7 +1,10 @@
 package client
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
 	"time"
 )
 
@@ -25,6 +28,16 @@ func NewPollingOptions() *PollingOptions {
 type getStateFunc func() (string, error)
 
 func PollForStateOrTimeout(getState getStateFunc, successState string, opts *PollingOptions) error {
+	return pollForStateOrTimeout(context.Background(), getState, successState, opts)
+}
+
+// PollForStateOrTimeoutWithContext is PollForStateOrTimeout that also stops waiting as soon as the context is done
+func PollForStateOrTimeoutWithContext(ctx context.Context, getState getStateFunc, successState string, opts *PollingOptions) error {
+	return pollForStateOrTimeout(ctx, getState, successState, opts)
+}
+
+// pollForStateOrTimeout is PollForStateOrTimeout that also stops waiting as soon as the context is done
+func pollForStateOrTimeout(ctx context.Context, getState getStateFunc, successState string, opts *PollingOptions) error {
 	if opts == nil {
 		opts = NewPollingOptions()
 	}
@@ -35,6 +48,8 @@ func PollForStateOrTimeout(getState getStateFunc, successState string, opts *Pol
 
 	for {
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case <-timeout:
 			return AsyncProcessTimeoutError
 		case <-ticker.C:
@@ -51,3 +66,31 @@ func PollForStateOrTimeout(getState getStateFunc, successState string, opts *Pol
 		}
 	}
 }
+
+// pollForLastOperation waits for the last operation of a service instance or binding to succeed or fail
+func pollForLastOperation(ctx context.Context, getLastOperation func() (*resource.LastOperation, error), opts *PollingOptions) error {
+	if opts == nil {
+		opts = NewPollingOptions()
+	}
+	return pollForStateOrTimeout(ctx, func() (string, error) {
+		op, err := getLastOperation()
+		if err != nil {
+			return "", err
+		}
+		if op.State == resource.LastOperationFailed {
+			return opts.FailedState, nil
+		}
+		return op.State, nil
+	}, resource.LastOperationSucceeded, opts)
+}
+
+// lastOperationError adds the broker's description of a failed last operation to the error
+func lastOperationError(resourceType, name string, op resource.LastOperation, err error) error {
+	if op.State != resource.LastOperationFailed {
+		return err
+	}
+	if op.Description != "" {
+		return fmt.Errorf("%s %s %s failed: %s: %w", resourceType, name, op.Type, op.Description, err)
+	}
+	return fmt.Errorf("%s %s %s failed: %w", resourceType, name, op.Type, err)
+}
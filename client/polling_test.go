@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"github.com/stretchr/testify/require"
 	"testing"
 	"time"
@@ -37,3 +38,15 @@ func TestPollForStateOrTimeout(t *testing.T) {
 	err = PollForStateOrTimeout(timeoutFn, "SUCCESS", noWaitOpts)
 	require.Equal(t, AsyncProcessTimeoutError, err)
 }
+
+func TestPollForStateOrTimeoutContext(t *testing.T) {
+	opts := NewPollingOptions()
+	opts.CheckInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := pollForStateOrTimeout(ctx, func() (string, error) {
+		return "PROCESSING", nil
+	}, "SUCCESS", opts)
+	require.ErrorIs(t, err, context.Canceled)
+}
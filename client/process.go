@@ -2,8 +2,10 @@ package client
 
 import (
 	"context"
+	"fmt"
 	"github.com/cloudfoundry-community/go-cfclient/v3/internal/path"
 	"net/url"
+	"sync"
 
 	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
 )
@@ -54,6 +56,66 @@ func (c *ProcessClient) Get(ctx context.Context, guid string) (*resource.Process
 	return &iso, nil
 }
 
+// GetAppStats fetches the stats of all the app's processes concurrently and rolls them up into a single report
+func (c *ProcessClient) GetAppStats(ctx context.Context, appGUID string) (*resource.AppStats, error) {
+	processes, err := c.ListForAppAll(ctx, appGUID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the first failure cancels the remaining requests and is the error returned
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	stats := make([]*resource.ProcessStats, len(processes))
+	for i, p := range processes {
+		wg.Add(1)
+		go func(i int, p *resource.Process) {
+			defer wg.Done()
+			s, err := c.GetStats(ctx, p.GUID)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("error getting stats for %s process %s: %w", p.Type, p.GUID, err)
+					cancel()
+				})
+				return
+			}
+			stats[i] = s
+		}(i, p)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	appStats := &resource.AppStats{
+		AppGUID:   appGUID,
+		Summary:   resource.SummarizeProcessStats(stats...),
+		Processes: make(map[string]*resource.AppProcessStats, len(processes)),
+	}
+	for i, p := range processes {
+		appStats.Processes[p.Type] = &resource.AppProcessStats{
+			ProcessGUID: p.GUID,
+			Summary:     stats[i].Summary(),
+			Stats:       stats[i],
+		}
+	}
+	return appStats, nil
+}
+
+// GetForAppByType returns the process of the specified type for the app, e.g. web
+func (c *ProcessClient) GetForAppByType(ctx context.Context, appGUID, processType string) (*resource.Process, error) {
+	var process resource.Process
+	err := c.client.get(ctx, path.Format("/v3/apps/%s/processes/%s", appGUID, processType), &process)
+	if err != nil {
+		return nil, err
+	}
+	return &process, nil
+}
+
 // GetStats for the specified process
 func (c *ProcessClient) GetStats(ctx context.Context, guid string) (*resource.ProcessStats, error) {
 	var stats resource.ProcessStats
@@ -64,6 +126,16 @@ func (c *ProcessClient) GetStats(ctx context.Context, guid string) (*resource.Pr
 	return &stats, nil
 }
 
+// GetStatsForApp for the process of the specified type for the app, e.g. web
+func (c *ProcessClient) GetStatsForApp(ctx context.Context, appGUID, processType string) (*resource.ProcessStats, error) {
+	var stats resource.ProcessStats
+	err := c.client.get(ctx, path.Format("/v3/apps/%s/processes/%s/stats", appGUID, processType), &stats)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
 // List pages all processes
 func (c *ProcessClient) List(ctx context.Context, opts *ProcessListOptions) ([]*resource.Process, *Pager, error) {
 	if opts == nil {
@@ -116,8 +188,28 @@ func (c *ProcessClient) ListForAppAll(ctx context.Context, appGUID string, opts
 
 // Scale the process using the specified scaling requirements
 func (c *ProcessClient) Scale(ctx context.Context, guid string, scale *resource.ProcessScale) (*resource.Process, error) {
+	err := c.checkScaleSupported(ctx, scale)
+	if err != nil {
+		return nil, err
+	}
+
 	var process resource.Process
-	_, err := c.client.post(ctx, path.Format("/v3/processes/%s/actions/scale", guid), scale, &process)
+	_, err = c.client.post(ctx, path.Format("/v3/processes/%s/actions/scale", guid), scale, &process)
+	if err != nil {
+		return nil, err
+	}
+	return &process, nil
+}
+
+// ScaleForApp scales the process of the specified type for the app using the specified scaling requirements
+func (c *ProcessClient) ScaleForApp(ctx context.Context, appGUID, processType string, scale *resource.ProcessScale) (*resource.Process, error) {
+	err := c.checkScaleSupported(ctx, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	var process resource.Process
+	_, err = c.client.post(ctx, path.Format("/v3/apps/%s/processes/%s/actions/scale", appGUID, processType), scale, &process)
 	if err != nil {
 		return nil, err
 	}
@@ -153,3 +245,18 @@ func (c *ProcessClient) Terminate(ctx context.Context, guid string, index int) e
 	_, err := c.client.delete(ctx, path.Format("/v3/processes/%s/instances/%d", guid, index))
 	return err
 }
+
+// TerminateInstanceForApp terminates an instance of the process of the specified type for the app. Health
+// management will eventually restart the instance.
+func (c *ProcessClient) TerminateInstanceForApp(ctx context.Context, appGUID, processType string, index int) error {
+	_, err := c.client.delete(ctx, path.Format("/v3/apps/%s/processes/%s/instances/%d", appGUID, processType, index))
+	return err
+}
+
+// checkScaleSupported returns an error if the scale uses features the CF API version doesn't support
+func (c *ProcessClient) checkScaleSupported(ctx context.Context, scale *resource.ProcessScale) error {
+	if scale.LogRateLimitInBytesPerSecond != nil {
+		return c.client.requireAPIVersion(ctx, "process log rate limits", MinAPIVersionLogRateLimit)
+	}
+	return nil
+}
@@ -2,10 +2,13 @@ package client
 
 import (
 	"context"
+	"github.com/cloudfoundry-community/go-cfclient/v3/config"
 	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
 	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
+	"github.com/stretchr/testify/require"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestProcesses(t *testing.T) {
@@ -43,6 +46,32 @@ func TestProcesses(t *testing.T) {
 				return c.Processes.GetStats(context.Background(), "ec4ff362-60c5-47a0-8246-2a134537c606")
 			},
 		},
+		{
+			Description: "Get process for app by type",
+			Route: testutil.MockRoute{
+				Method:   "GET",
+				Endpoint: "/v3/apps/2a550283-9245-493e-af36-3e1b7d7a3bb0/processes/web",
+				Output:   g.Single(process),
+				Status:   http.StatusOK,
+			},
+			Expected: process,
+			Action: func(c *Client, t *testing.T) (any, error) {
+				return c.Processes.GetForAppByType(context.Background(), "2a550283-9245-493e-af36-3e1b7d7a3bb0", "web")
+			},
+		},
+		{
+			Description: "Get process stats for app",
+			Route: testutil.MockRoute{
+				Method:   "GET",
+				Endpoint: "/v3/apps/2a550283-9245-493e-af36-3e1b7d7a3bb0/processes/web/stats",
+				Output:   g.Single(processStats),
+				Status:   http.StatusOK,
+			},
+			Expected: processStats,
+			Action: func(c *Client, t *testing.T) (any, error) {
+				return c.Processes.GetStatsForApp(context.Background(), "2a550283-9245-493e-af36-3e1b7d7a3bb0", "web")
+			},
+		},
 		{
 			Description: "List all processes",
 			Route: testutil.MockRoute{
@@ -130,6 +159,118 @@ func TestProcesses(t *testing.T) {
 				return nil, c.Processes.Terminate(context.Background(), "ec4ff362-60c5-47a0-8246-2a134537c606", 0)
 			},
 		},
+		{
+			Description: "Scale process for app",
+			Route: testutil.MockRoute{
+				Method:   "POST",
+				Endpoint: "/v3/apps/2a550283-9245-493e-af36-3e1b7d7a3bb0/processes/web/actions/scale",
+				Output:   g.Single(process),
+				Status:   http.StatusAccepted,
+				PostForm: `{ "instances": 5, "memory_in_mb": 256, "disk_in_mb": 1024 }`,
+			},
+			Expected: process,
+			Action: func(c *Client, t *testing.T) (any, error) {
+				r := resource.NewProcessScale().
+					WithInstances(5).
+					WithMemoryInMB(256).
+					WithDiskInMB(1024)
+				return c.Processes.ScaleForApp(context.Background(), "2a550283-9245-493e-af36-3e1b7d7a3bb0", "web", r)
+			},
+		},
+		{
+			Description: "Terminate process instance for app",
+			Route: testutil.MockRoute{
+				Method:   "DELETE",
+				Endpoint: "/v3/apps/2a550283-9245-493e-af36-3e1b7d7a3bb0/processes/web/instances/1",
+				Status:   http.StatusNoContent,
+			},
+			Action: func(c *Client, t *testing.T) (any, error) {
+				return nil, c.Processes.TerminateInstanceForApp(context.Background(), "2a550283-9245-493e-af36-3e1b7d7a3bb0", "web", 1)
+			},
+		},
 	}
 	ExecuteTests(tests, t)
 }
+
+func TestProcessesGetAppStats(t *testing.T) {
+	g := testutil.NewObjectJSONGenerator(78)
+	web := g.ProcessOfType("web")
+	worker := g.ProcessOfType("worker")
+	workerStats := `{
+		"resources": [
+			{ "type": "worker", "index": 0, "state": "RUNNING", "usage": { "cpu": 0.5, "mem": 256, "disk": 100 }, "uptime": 60, "mem_quota": 1024, "disk_quota": 1000 },
+			{ "type": "worker", "index": 1, "state": "CRASHED", "usage": { "cpu": 0, "mem": 0, "disk": 0 }, "uptime": 0, "mem_quota": 1024, "disk_quota": 1000 }
+		]
+	}`
+
+	serverURL := testutil.SetupMultiple([]testutil.MockRoute{
+		{
+			Method:   "GET",
+			Endpoint: "/v3/apps/2a550283-9245-493e-af36-3e1b7d7a3bb0/processes",
+			Output:   g.Paged([]string{web.JSON, worker.JSON}),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   "GET",
+			Endpoint: "/v3/processes/" + web.GUID + "/stats",
+			Output:   g.Single(g.ProcessStats().JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   "GET",
+			Endpoint: "/v3/processes/" + worker.GUID + "/stats",
+			Output:   []string{workerStats},
+			Status:   http.StatusOK,
+		},
+	}, t)
+	defer testutil.Teardown()
+
+	c, _ := config.NewToken(serverURL, "foobar")
+	cl, err := New(c)
+	require.NoError(t, err)
+
+	stats, err := cl.Processes.GetAppStats(context.Background(), "2a550283-9245-493e-af36-3e1b7d7a3bb0")
+	require.NoError(t, err)
+	require.Equal(t, "2a550283-9245-493e-af36-3e1b7d7a3bb0", stats.AppGUID)
+	require.Len(t, stats.Processes, 2)
+	require.Equal(t, web.GUID, stats.Processes["web"].ProcessGUID)
+	require.Equal(t, 1, stats.Processes["web"].Summary.Running)
+	require.Equal(t, worker.GUID, stats.Processes["worker"].ProcessGUID)
+	require.Equal(t, 1, stats.Processes["worker"].Summary.Crashed)
+	require.Equal(t, 25.0, stats.Processes["worker"].Summary.MaxMemoryPercent)
+
+	require.Equal(t, 3, stats.Summary.Instances)
+	require.Equal(t, 2, stats.Summary.Running)
+	require.Equal(t, 1, stats.Summary.Crashed)
+	require.Equal(t, 0.5, stats.Summary.MaxCPU)
+	require.Equal(t, 60*time.Second, stats.Summary.MinUptime)
+	require.Equal(t, 9042*time.Second, stats.Summary.MaxUptime)
+}
+
+func TestProcessesGetAppStatsError(t *testing.T) {
+	g := testutil.NewObjectJSONGenerator(78)
+	web := g.ProcessOfType("web")
+	serverURL := testutil.SetupMultiple([]testutil.MockRoute{
+		{
+			Method:   "GET",
+			Endpoint: "/v3/apps/2a550283-9245-493e-af36-3e1b7d7a3bb0/processes",
+			Output:   g.Paged([]string{web.JSON}),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   "GET",
+			Endpoint: "/v3/processes/" + web.GUID + "/stats",
+			Output:   []string{`{"errors":[{"code":10010,"title":"CF-ResourceNotFound","detail":"Process not found"}]}`},
+			Status:   http.StatusNotFound,
+		},
+	}, t)
+	defer testutil.Teardown()
+
+	c, _ := config.NewToken(serverURL, "foobar")
+	cl, err := New(c)
+	require.NoError(t, err)
+
+	_, err = cl.Processes.GetAppStats(context.Background(), "2a550283-9245-493e-af36-3e1b7d7a3bb0")
+	require.Error(t, err)
+	require.True(t, resource.IsResourceNotFoundError(err))
+}
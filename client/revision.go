@@ -80,11 +80,15 @@ func (c *RevisionClient) ListForAppAll(ctx context.Context, appGUID string, opts
 
 // ListForAppDeployed pages deployed revisions that are associated with the specified app
 func (c *RevisionClient) ListForAppDeployed(ctx context.Context, appGUID string, opts *RevisionListOptions) ([]*resource.Revision, *Pager, error) {
+	err := c.client.requireAPIVersion(ctx, "listing deployed revisions", MinAPIVersionDeployedRevisions)
+	if err != nil {
+		return nil, nil, err
+	}
 	if opts == nil {
 		opts = NewRevisionListOptions()
 	}
 	var res resource.RevisionList
-	err := c.client.get(ctx, path.Format("/v3/apps/%s/revisions/deployed?%s", appGUID, opts.ToQueryString()), &res)
+	err = c.client.get(ctx, path.Format("/v3/apps/%s/revisions/deployed?%s", appGUID, opts.ToQueryString()), &res)
 	if err != nil {
 		return nil, nil, err
 	}
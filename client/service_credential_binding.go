@@ -2,6 +2,8 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/cloudfoundry-community/go-cfclient/v3/internal/path"
 	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
 	"net/url"
@@ -52,12 +54,86 @@ func (c *ServiceCredentialBindingClient) Create(ctx context.Context, r *resource
 	return "", &d, nil
 }
 
+// CreateAndWait creates a new service credential binding and waits for both the bind job and the binding's
+// last operation to finish, returning the binding along with its credentials
+//
+// If the broker fails to bind the returned error includes the broker's description of the failure.
+func (c *ServiceCredentialBindingClient) CreateAndWait(ctx context.Context, r *resource.ServiceCredentialBindingCreate, opts *PollingOptions) (*resource.ServiceCredentialBindingWithDetails, error) {
+	if r.Relationships.ServiceInstance == nil || r.Relationships.ServiceInstance.Data == nil {
+		return nil, errors.New("expected the service credential binding to have a service instance relationship")
+	}
+	jobGUID, binding, err := c.Create(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if jobGUID != "" {
+		jobErr := c.client.Jobs.PollComplete(ctx, jobGUID, opts)
+
+		// the binding exists as soon as the create is accepted, even when the broker later fails
+		binding, err = c.Single(ctx, newServiceCredentialBindingCreateListOptions(r))
+		if err != nil {
+			if jobErr != nil {
+				return nil, jobErr
+			}
+			return nil, err
+		}
+		if jobErr != nil {
+			return nil, serviceCredentialBindingError(binding, jobErr)
+		}
+		binding, err = c.pollLastOperation(ctx, binding.GUID, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	details, err := c.GetDetails(ctx, binding.GUID)
+	if err != nil {
+		return nil, err
+	}
+	return &resource.ServiceCredentialBindingWithDetails{
+		ServiceCredentialBinding: *binding,
+		Details:                  *details,
+	}, nil
+}
+
 // Delete the specified service credential binding
 func (c *ServiceCredentialBindingClient) Delete(ctx context.Context, guid string) error {
 	_, err := c.client.delete(ctx, path.Format("/v3/service_credential_bindings/%s", guid))
 	return err
 }
 
+// DeleteAndWait deletes the specified service credential binding and waits for both the unbind job and the
+// binding's last operation to finish
+//
+// If the broker fails to unbind the returned error includes the broker's description of the failure.
+func (c *ServiceCredentialBindingClient) DeleteAndWait(ctx context.Context, guid string, opts *PollingOptions) error {
+	jobGUID, err := c.client.delete(ctx, path.Format("/v3/service_credential_bindings/%s", guid))
+	if err != nil {
+		return err
+	}
+	if jobGUID != "" {
+		err = c.client.Jobs.PollComplete(ctx, jobGUID, opts)
+		if err != nil {
+			binding, getErr := c.Get(ctx, guid)
+			if getErr != nil {
+				return err
+			}
+			return serviceCredentialBindingError(binding, err)
+		}
+	}
+
+	_, err = c.pollLastOperation(ctx, guid, opts)
+	if resource.IsResourceNotFoundError(err) {
+		return nil
+	}
+	if err == nil {
+		// the last operation succeeded but the binding still exists
+		return fmt.Errorf("service credential binding %s still exists after being deleted", guid)
+	}
+	return err
+}
+
 // First returns the first service credential binding matching the options or an error when less than 1 match
 func (c *ServiceCredentialBindingClient) First(ctx context.Context, opts *ServiceCredentialBindingListOptions) (*resource.ServiceCredentialBinding, error) {
 	return First[*ServiceCredentialBindingListOptions, *resource.ServiceCredentialBinding](opts, func(opts *ServiceCredentialBindingListOptions) ([]*resource.ServiceCredentialBinding, *Pager, error) {
@@ -214,6 +290,38 @@ func (c *ServiceCredentialBindingClient) ListIncludeServiceInstancesAll(ctx cont
 	return all, allServiceInstances, nil
 }
 
+// RotateKey creates a new service key, hands it to use, and then deletes the old service key
+//
+// The old key is only deleted after use succeeds, so consumers can be switched to the new credentials before
+// the old credentials are revoked. If use fails the new key is left in place and returned along with the error,
+// so the caller can retry or clean it up.
+func (c *ServiceCredentialBindingClient) RotateKey(ctx context.Context, oldGUID string, r *resource.ServiceCredentialBindingCreate,
+	use func(newKey *resource.ServiceCredentialBindingWithDetails) error, opts *PollingOptions) (*resource.ServiceCredentialBindingWithDetails, error) {
+	if r.Type != "key" {
+		return nil, fmt.Errorf("expected a service credential binding of type key, but got %s", r.Type)
+	}
+	oldKey, err := c.Get(ctx, oldGUID)
+	if err != nil {
+		return nil, err
+	}
+
+	newKey, err := c.CreateAndWait(ctx, r, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = use(newKey)
+	if err != nil {
+		return newKey, fmt.Errorf("not deleting service key %s, new service key %s was rejected: %w",
+			oldKey.Name, newKey.Name, err)
+	}
+
+	err = c.DeleteAndWait(ctx, oldKey.GUID, opts)
+	if err != nil {
+		return newKey, fmt.Errorf("error deleting service key %s after rotating to %s: %w", oldKey.Name, newKey.Name, err)
+	}
+	return newKey, nil
+}
+
 // Single returns a single service credential binding matching the options or an error if not exactly 1 match
 func (c *ServiceCredentialBindingClient) Single(ctx context.Context, opts *ServiceCredentialBindingListOptions) (*resource.ServiceCredentialBinding, error) {
 	return Single[*ServiceCredentialBindingListOptions, *resource.ServiceCredentialBinding](opts, func(opts *ServiceCredentialBindingListOptions) ([]*resource.ServiceCredentialBinding, *Pager, error) {
@@ -230,3 +338,43 @@ func (c *ServiceCredentialBindingClient) Update(ctx context.Context, guid string
 	}
 	return &d, nil
 }
+
+// pollLastOperation waits for the binding last operation to succeed or fail, returning the binding once the
+// operation has succeeded
+func (c *ServiceCredentialBindingClient) pollLastOperation(ctx context.Context, guid string, opts *PollingOptions) (*resource.ServiceCredentialBinding, error) {
+	var binding *resource.ServiceCredentialBinding
+	err := pollForLastOperation(ctx, func() (*resource.LastOperation, error) {
+		var err error
+		binding, err = c.Get(ctx, guid)
+		if err != nil {
+			return nil, err
+		}
+		return &binding.LastOperation, nil
+	}, opts)
+	if err != nil {
+		return nil, serviceCredentialBindingError(binding, err)
+	}
+	return binding, nil
+}
+
+// newServiceCredentialBindingCreateListOptions creates list options that match the binding being created
+func newServiceCredentialBindingCreateListOptions(r *resource.ServiceCredentialBindingCreate) *ServiceCredentialBindingListOptions {
+	opts := NewServiceCredentialBindingListOptions()
+	opts.Type = Filter{Values: []string{r.Type}}
+	opts.ServiceInstanceGUIDs = Filter{Values: []string{r.Relationships.ServiceInstance.Data.GUID}}
+	if r.Name != nil {
+		opts.Names = Filter{Values: []string{*r.Name}}
+	}
+	if r.Relationships.App != nil && r.Relationships.App.Data != nil {
+		opts.AppGUIDs = Filter{Values: []string{r.Relationships.App.Data.GUID}}
+	}
+	return opts
+}
+
+// serviceCredentialBindingError adds the broker's description of a failed binding last operation to the error
+func serviceCredentialBindingError(binding *resource.ServiceCredentialBinding, err error) error {
+	if binding == nil {
+		return err
+	}
+	return lastOperationError("service credential binding", binding.Name, binding.LastOperation, err)
+}
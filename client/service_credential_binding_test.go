@@ -2,10 +2,15 @@ package client
 
 import (
 	"context"
+	"errors"
+	"github.com/cloudfoundry-community/go-cfclient/v3/config"
 	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
 	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
+	"github.com/stretchr/testify/require"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestServiceCredentialBindings(t *testing.T) {
@@ -262,3 +267,140 @@ func TestServiceCredentialBindings(t *testing.T) {
 	}
 	ExecuteTests(tests, t)
 }
+
+func TestServiceCredentialBindingsWait(t *testing.T) {
+	g := testutil.NewObjectJSONGenerator(1)
+	job := g.Job("COMPLETE")
+	creating := g.ServiceCredentialBindingWithLastOperation("create", "in progress", "Binding")
+	created := g.ServiceCredentialBindingWithLastOperation("create", "succeeded", "Bound")
+	createdSameGUID := strings.Replace(created.JSON, created.GUID, creating.GUID, -1)
+	failed := g.ServiceCredentialBindingWithLastOperation("create", "failed", "Too many keys")
+	failedSameGUID := strings.Replace(failed.JSON, failed.GUID, creating.GUID, -1)
+	oldKey := g.ServiceCredentialBinding()
+	details := g.ServiceCredentialBindingDetails().JSON
+	notFound := `{"errors":[{"code":10010,"title":"CF-ResourceNotFound","detail":"Binding not found"}]}`
+
+	opts := NewPollingOptions()
+	opts.CheckInterval = time.Millisecond
+
+	newClient := func(routes []testutil.MockRoute) *Client {
+		serverURL := testutil.SetupMultiple(routes, t)
+		c, _ := config.NewToken(serverURL, "foobar")
+		cl, err := New(c)
+		require.NoError(t, err)
+		return cl
+	}
+	createRoutes := func(bindingOutput ...string) []testutil.MockRoute {
+		return []testutil.MockRoute{
+			{
+				Method:           "POST",
+				Endpoint:         "/v3/service_credential_bindings",
+				Status:           http.StatusAccepted,
+				RedirectLocation: "https://api.example.org/v3/jobs/" + job.GUID,
+			},
+			{
+				Method:   "GET",
+				Endpoint: "/v3/jobs/" + job.GUID,
+				Output:   []string{job.JSON, job.JSON},
+				Status:   http.StatusOK,
+			},
+			{
+				Method:      "GET",
+				Endpoint:    "/v3/service_credential_bindings",
+				Output:      g.SinglePaged(creating.JSON),
+				Status:      http.StatusOK,
+				QueryString: "names=my-key&page=1&per_page=50&service_instance_guids=8bfe4c1b-9e18-45b1-83be-124163f31f9e&type=key",
+			},
+			{
+				Method:   "GET",
+				Endpoint: "/v3/service_credential_bindings/" + creating.GUID,
+				Output:   bindingOutput,
+				Status:   http.StatusOK,
+			},
+			{
+				Method:   "GET",
+				Endpoint: "/v3/service_credential_bindings/" + creating.GUID + "/details",
+				Output:   []string{details},
+				Status:   http.StatusOK,
+			},
+		}
+	}
+	create := resource.NewServiceCredentialBindingCreateKey("8bfe4c1b-9e18-45b1-83be-124163f31f9e", "my-key")
+
+	t.Run("create and wait returns the credentials", func(t *testing.T) {
+		defer testutil.Teardown()
+		cl := newClient(createRoutes(creating.JSON, createdSameGUID))
+		key, err := cl.ServiceCredentialBindings.CreateAndWait(context.Background(), create, opts)
+		require.NoError(t, err)
+		require.Equal(t, creating.GUID, key.GUID)
+		require.Equal(t, resource.LastOperationSucceeded, key.LastOperation.State)
+		require.Equal(t, "supers3cret", key.Details.Credentials["password"])
+	})
+
+	t.Run("create surfaces the broker description", func(t *testing.T) {
+		defer testutil.Teardown()
+		cl := newClient(createRoutes(creating.JSON, failedSameGUID))
+		_, err := cl.ServiceCredentialBindings.CreateAndWait(context.Background(), create, opts)
+		require.ErrorIs(t, err, AsyncProcessFailedError)
+		require.ErrorContains(t, err, "Too many keys")
+	})
+
+	rotateRoutes := func(oldKeyRoutes ...testutil.MockRoute) []testutil.MockRoute {
+		return append(createRoutes(creating.JSON, createdSameGUID), oldKeyRoutes...)
+	}
+
+	t.Run("rotate deletes the old key after the new key is used", func(t *testing.T) {
+		defer testutil.Teardown()
+		cl := newClient(rotateRoutes(
+			testutil.MockRoute{
+				Method:   "GET",
+				Endpoint: "/v3/service_credential_bindings/" + oldKey.GUID,
+				Output:   []string{oldKey.JSON, notFound},
+				Statuses: []int{http.StatusOK, http.StatusNotFound},
+			},
+			testutil.MockRoute{
+				Method:   "DELETE",
+				Endpoint: "/v3/service_credential_bindings/" + oldKey.GUID,
+				Status:   http.StatusNoContent,
+			},
+		))
+
+		var used *resource.ServiceCredentialBindingWithDetails
+		newKey, err := cl.ServiceCredentialBindings.RotateKey(context.Background(), oldKey.GUID, create,
+			func(k *resource.ServiceCredentialBindingWithDetails) error {
+				used = k
+				return nil
+			}, opts)
+		require.NoError(t, err)
+		require.Equal(t, used, newKey)
+		require.Equal(t, creating.GUID, newKey.GUID)
+	})
+
+	t.Run("rotate keeps the old key when the new key is rejected", func(t *testing.T) {
+		defer testutil.Teardown()
+		// no delete route, deleting the old key would fail the test
+		cl := newClient(rotateRoutes(
+			testutil.MockRoute{
+				Method:   "GET",
+				Endpoint: "/v3/service_credential_bindings/" + oldKey.GUID,
+				Output:   []string{oldKey.JSON},
+				Status:   http.StatusOK,
+			},
+		))
+		newKey, err := cl.ServiceCredentialBindings.RotateKey(context.Background(), oldKey.GUID, create,
+			func(k *resource.ServiceCredentialBindingWithDetails) error {
+				return errors.New("rejected")
+			}, opts)
+		require.ErrorContains(t, err, "rejected")
+		require.Equal(t, creating.GUID, newKey.GUID)
+	})
+
+	t.Run("rotate requires a service key", func(t *testing.T) {
+		r := resource.NewServiceCredentialBindingCreateApp("8bfe4c1b-9e18-45b1-83be-124163f31f9e", "74f7c078-0934-470f-9883-4fddss5b8f13")
+		_, err := (&ServiceCredentialBindingClient{}).RotateKey(context.Background(), oldKey.GUID, r,
+			func(k *resource.ServiceCredentialBindingWithDetails) error {
+				return nil
+			}, opts)
+		require.Error(t, err)
+	})
+}
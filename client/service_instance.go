@@ -3,6 +3,8 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/cloudfoundry-community/go-cfclient/v3/internal/path"
 	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
 	"net/url"
@@ -45,6 +47,40 @@ func (c *ServiceInstanceClient) CreateManaged(ctx context.Context, r *resource.S
 	return jobGUID, nil
 }
 
+// CreateManagedAndWait requests a new service instance from a broker and waits for both the create job and the
+// broker's provisioning last operation to finish, returning the provisioned service instance
+//
+// Brokers may take a long time to provision, so the polling timeout applies separately to the job and the last
+// operation and should be set accordingly. If provisioning fails the returned error includes the broker's
+// description of the failure.
+func (c *ServiceInstanceClient) CreateManagedAndWait(ctx context.Context, r *resource.ServiceInstanceCreate, opts *PollingOptions) (*resource.ServiceInstance, error) {
+	if r.Relationships.Space == nil || r.Relationships.Space.Data == nil {
+		return nil, errors.New("expected the service instance to have a space relationship")
+	}
+	jobGUID, err := c.CreateManaged(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	jobErr := c.client.Jobs.PollComplete(ctx, jobGUID, opts)
+
+	// the instance exists as soon as the create is accepted, even when the broker later fails
+	si, err := c.Single(ctx, &ServiceInstanceListOptions{
+		ListOptions: NewListOptions(),
+		Names:       Filter{Values: []string{r.Name}},
+		SpaceGUIDs:  Filter{Values: []string{r.Relationships.Space.Data.GUID}},
+	})
+	if err != nil {
+		if jobErr != nil {
+			return nil, jobErr
+		}
+		return nil, err
+	}
+	if jobErr != nil {
+		return nil, serviceInstanceError(si, jobErr)
+	}
+	return c.pollLastOperation(ctx, si.GUID, opts)
+}
+
 // CreateUserProvided creates a new user provided service instance. User provided service instances
 // do not require interactions with service brokers.
 func (c *ServiceInstanceClient) CreateUserProvided(ctx context.Context, r *resource.ServiceInstanceCreate) (*resource.ServiceInstance, error) {
@@ -61,6 +97,37 @@ func (c *ServiceInstanceClient) Delete(ctx context.Context, guid string) (string
 	return c.client.delete(ctx, path.Format("/v3/service_instances/%s", guid))
 }
 
+// DeleteAndWait deletes the specified service instance and waits for both the delete job and the broker's
+// deprovisioning last operation to finish
+//
+// If deprovisioning fails the returned error includes the broker's description of the failure.
+func (c *ServiceInstanceClient) DeleteAndWait(ctx context.Context, guid string, opts *PollingOptions) error {
+	jobGUID, err := c.Delete(ctx, guid)
+	if err != nil {
+		return err
+	}
+	if jobGUID != "" {
+		err = c.client.Jobs.PollComplete(ctx, jobGUID, opts)
+		if err != nil {
+			si, getErr := c.Get(ctx, guid)
+			if getErr != nil {
+				return err
+			}
+			return serviceInstanceError(si, err)
+		}
+	}
+
+	_, err = c.pollLastOperation(ctx, guid, opts)
+	if resource.IsResourceNotFoundError(err) {
+		return nil
+	}
+	if err == nil {
+		// the last operation succeeded but the instance still exists
+		return fmt.Errorf("service instance %s still exists after being deleted", guid)
+	}
+	return err
+}
+
 // First returns the first service instance matching the options or an error when less than 1 match
 func (c *ServiceInstanceClient) First(ctx context.Context, opts *ServiceInstanceListOptions) (*resource.ServiceInstance, error) {
 	return First[*ServiceInstanceListOptions, *resource.ServiceInstance](opts, func(opts *ServiceInstanceListOptions) ([]*resource.ServiceInstance, *Pager, error) {
@@ -228,6 +295,30 @@ func (c *ServiceInstanceClient) UpdateManaged(ctx context.Context, guid string,
 	return "", &si, nil
 }
 
+// UpdateManagedAndWait updates the specified attributes of the managed service instance and waits for both the
+// update job (if any) and the broker's last operation to finish, returning the updated service instance
+//
+// If the update fails the returned error includes the broker's description of the failure.
+func (c *ServiceInstanceClient) UpdateManagedAndWait(ctx context.Context, guid string, r *resource.ServiceInstanceManagedUpdate, opts *PollingOptions) (*resource.ServiceInstance, error) {
+	jobGUID, si, err := c.UpdateManaged(ctx, guid, r)
+	if err != nil {
+		return nil, err
+	}
+	if jobGUID == "" {
+		return si, nil
+	}
+
+	err = c.client.Jobs.PollComplete(ctx, jobGUID, opts)
+	if err != nil {
+		si, getErr := c.Get(ctx, guid)
+		if getErr != nil {
+			return nil, err
+		}
+		return nil, serviceInstanceError(si, err)
+	}
+	return c.pollLastOperation(ctx, guid, opts)
+}
+
 // UpdateUserProvided updates the specified attributes of the user-provided service instance returning a
 // service instance object
 func (c *ServiceInstanceClient) UpdateUserProvided(ctx context.Context, guid string, r *resource.ServiceInstanceUserProvidedUpdate) (*resource.ServiceInstance, error) {
@@ -238,3 +329,29 @@ func (c *ServiceInstanceClient) UpdateUserProvided(ctx context.Context, guid str
 	}
 	return &si, nil
 }
+
+// pollLastOperation waits for the service instance last operation to succeed or fail, returning the service
+// instance once the operation has succeeded
+func (c *ServiceInstanceClient) pollLastOperation(ctx context.Context, guid string, opts *PollingOptions) (*resource.ServiceInstance, error) {
+	var si *resource.ServiceInstance
+	err := pollForLastOperation(ctx, func() (*resource.LastOperation, error) {
+		var err error
+		si, err = c.Get(ctx, guid)
+		if err != nil {
+			return nil, err
+		}
+		return &si.LastOperation, nil
+	}, opts)
+	if err != nil {
+		return nil, serviceInstanceError(si, err)
+	}
+	return si, nil
+}
+
+// serviceInstanceError adds the broker's description of a failed service instance last operation to the error
+func serviceInstanceError(si *resource.ServiceInstance, err error) error {
+	if si == nil {
+		return err
+	}
+	return lastOperationError("service instance", si.Name, si.LastOperation, err)
+}
@@ -3,11 +3,14 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"github.com/cloudfoundry-community/go-cfclient/v3/config"
 	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
 	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
 	"github.com/stretchr/testify/require"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestServiceInstances(t *testing.T) {
@@ -292,3 +295,175 @@ func TestServiceInstances(t *testing.T) {
 	}
 	ExecuteTests(tests, t)
 }
+
+func TestServiceInstancesWait(t *testing.T) {
+	g := testutil.NewObjectJSONGenerator(156)
+	job := g.Job("COMPLETE")
+	failedJob := g.Job("FAILED")
+	inProgress := g.ServiceInstanceWithLastOperation("create", "in progress", "Provisioning")
+	succeeded := g.ServiceInstanceWithLastOperation("create", "succeeded", "Provisioned")
+	failed := g.ServiceInstanceWithLastOperation("create", "failed", "Quota exceeded for plan xlarge")
+	updating := g.ServiceInstanceWithLastOperation("update", "in progress", "Updating")
+	updated := g.ServiceInstanceWithLastOperation("update", "succeeded", "Updated")
+	deleting := g.ServiceInstanceWithLastOperation("delete", "in progress", "Deprovisioning")
+	deleteFailed := g.ServiceInstanceWithLastOperation("delete", "failed", "Instance has active backups")
+	notFound := `{"errors":[{"code":10010,"title":"CF-ResourceNotFound","detail":"Service instance not found"}]}`
+
+	opts := NewPollingOptions()
+	opts.CheckInterval = time.Millisecond
+
+	newClient := func(routes []testutil.MockRoute) *Client {
+		serverURL := testutil.SetupMultiple(routes, t)
+		c, _ := config.NewToken(serverURL, "foobar")
+		cl, err := New(c)
+		require.NoError(t, err)
+		return cl
+	}
+	createRoutes := func(job *testutil.JSONResource, instanceOutput ...string) []testutil.MockRoute {
+		return []testutil.MockRoute{
+			{
+				Method:           "POST",
+				Endpoint:         "/v3/service_instances",
+				Status:           http.StatusAccepted,
+				RedirectLocation: "https://api.example.org/v3/jobs/" + job.GUID,
+			},
+			{
+				Method:   "GET",
+				Endpoint: "/v3/jobs/" + job.GUID,
+				Output:   []string{job.JSON, job.JSON},
+				Status:   http.StatusOK,
+			},
+			{
+				Method:   "GET",
+				Endpoint: "/v3/service_instances",
+				Output:   g.SinglePaged(instanceOutput[0]),
+				Status:   http.StatusOK,
+			},
+			{
+				Method:   "GET",
+				Endpoint: "/v3/service_instances/" + inProgress.GUID,
+				Output:   instanceOutput,
+				Status:   http.StatusOK,
+			},
+		}
+	}
+	create := resource.NewServiceInstanceCreateManaged("my_service_instance",
+		"7304bc3c-7010-11ea-8840-48bf6bec2d78", "e0e4417c-74ee-11ea-a604-48bf6bec2d78")
+
+	t.Run("create and wait for last operation", func(t *testing.T) {
+		defer testutil.Teardown()
+		// the list returns the in progress instance, then the last operation is polled by guid
+		succeededSameGUID := strings.Replace(succeeded.JSON, succeeded.GUID, inProgress.GUID, 1)
+		cl := newClient(createRoutes(job, inProgress.JSON, inProgress.JSON, succeededSameGUID))
+		si, err := cl.ServiceInstances.CreateManagedAndWait(context.Background(), create, opts)
+		require.NoError(t, err)
+		require.Equal(t, inProgress.GUID, si.GUID)
+		require.Equal(t, resource.LastOperationSucceeded, si.LastOperation.State)
+	})
+
+	t.Run("create surfaces the broker description", func(t *testing.T) {
+		defer testutil.Teardown()
+		failedSameGUID := strings.Replace(failed.JSON, failed.GUID, inProgress.GUID, 1)
+		cl := newClient(createRoutes(job, inProgress.JSON, failedSameGUID))
+		_, err := cl.ServiceInstances.CreateManagedAndWait(context.Background(), create, opts)
+		require.ErrorIs(t, err, AsyncProcessFailedError)
+		require.ErrorContains(t, err, "Quota exceeded for plan xlarge")
+	})
+
+	t.Run("create job failure surfaces the broker description", func(t *testing.T) {
+		defer testutil.Teardown()
+		cl := newClient(createRoutes(failedJob, failed.JSON))
+		_, err := cl.ServiceInstances.CreateManagedAndWait(context.Background(), create, opts)
+		require.Error(t, err)
+		require.ErrorContains(t, err, "Quota exceeded for plan xlarge")
+	})
+
+	t.Run("create honors context cancellation", func(t *testing.T) {
+		defer testutil.Teardown()
+		cl := newClient(createRoutes(job, inProgress.JSON, inProgress.JSON))
+		longOpts := NewPollingOptions()
+		longOpts.CheckInterval = time.Hour
+		longOpts.Timeout = time.Hour
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := cl.ServiceInstances.CreateManagedAndWait(ctx, create, longOpts)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("update and wait for last operation", func(t *testing.T) {
+		defer testutil.Teardown()
+		cl := newClient([]testutil.MockRoute{
+			{
+				Method:           "PATCH",
+				Endpoint:         "/v3/service_instances/" + updating.GUID,
+				Status:           http.StatusAccepted,
+				RedirectLocation: "https://api.example.org/v3/jobs/" + job.GUID,
+				PostForm:         `{ "tags": ["foo"] }`,
+			},
+			{
+				Method:   "GET",
+				Endpoint: "/v3/jobs/" + job.GUID,
+				Output:   []string{job.JSON},
+				Status:   http.StatusOK,
+			},
+			{
+				Method:   "GET",
+				Endpoint: "/v3/service_instances/" + updating.GUID,
+				Output:   []string{updating.JSON, updated.JSON},
+				Status:   http.StatusOK,
+			},
+		})
+		r := &resource.ServiceInstanceManagedUpdate{Tags: []string{"foo"}}
+		si, err := cl.ServiceInstances.UpdateManagedAndWait(context.Background(), updating.GUID, r, opts)
+		require.NoError(t, err)
+		require.Equal(t, "update", si.LastOperation.Type)
+		require.Equal(t, resource.LastOperationSucceeded, si.LastOperation.State)
+	})
+
+	t.Run("delete and wait until gone", func(t *testing.T) {
+		defer testutil.Teardown()
+		cl := newClient([]testutil.MockRoute{
+			{
+				Method:           "DELETE",
+				Endpoint:         "/v3/service_instances/" + deleting.GUID,
+				Status:           http.StatusAccepted,
+				RedirectLocation: "https://api.example.org/v3/jobs/" + job.GUID,
+			},
+			{
+				Method:   "GET",
+				Endpoint: "/v3/jobs/" + job.GUID,
+				Output:   []string{job.JSON},
+				Status:   http.StatusOK,
+			},
+			{
+				Method:   "GET",
+				Endpoint: "/v3/service_instances/" + deleting.GUID,
+				Output:   []string{notFound},
+				Status:   http.StatusNotFound,
+			},
+		})
+		err := cl.ServiceInstances.DeleteAndWait(context.Background(), deleting.GUID, opts)
+		require.NoError(t, err)
+	})
+
+	t.Run("delete surfaces the broker description", func(t *testing.T) {
+		defer testutil.Teardown()
+		deleteFailedSameGUID := strings.Replace(deleteFailed.JSON, deleteFailed.GUID, deleting.GUID, 1)
+		cl := newClient([]testutil.MockRoute{
+			{
+				Method:   "DELETE",
+				Endpoint: "/v3/service_instances/" + deleting.GUID,
+				Status:   http.StatusNoContent,
+			},
+			{
+				Method:   "GET",
+				Endpoint: "/v3/service_instances/" + deleting.GUID,
+				Output:   []string{deleting.JSON, deleteFailedSameGUID},
+				Status:   http.StatusOK,
+			},
+		})
+		err := cl.ServiceInstances.DeleteAndWait(context.Background(), deleting.GUID, opts)
+		require.ErrorIs(t, err, AsyncProcessFailedError)
+		require.ErrorContains(t, err, "Instance has active backups")
+	})
+}
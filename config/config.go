@@ -2,10 +2,10 @@ package config
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"golang.org/x/oauth2"
 	"net/http"
 	"net/url"
 	"os"
@@ -33,6 +33,12 @@ type Config struct {
 	baseHTTPClient    *http.Client
 	requestTimeout    time.Duration
 	skipTLSValidation bool
+	caCertPool        *x509.CertPool
+	clientCerts       []tls.Certificate
+
+	transportOptions    *TransportOptions
+	proxy               func(*http.Request) (*url.URL, error)
+	endpointHTTPClients map[Endpoint]*http.Client
 }
 
 type cfHomeConfig struct {
@@ -141,7 +147,9 @@ func NewFromCFHomeDir(cfHomeDir string) (*Config, error) {
 // # The TLS and Timeout values on the http.Client will be set to match the config
 //
 // This is useful if you need to configure advanced http.Client or http.Transport settings,
-// most consumers will not need to use this.
+// most consumers will not need to use this. The http.Client's transport is used as-is, so any proxy or
+// transport options configured earlier with WithProxy or WithTransportOptions no longer apply, call
+// those after WithHTTPClient to apply them to the new client's http.Transport.
 func (c *Config) WithHTTPClient(httpClient *http.Client) {
 	c.baseHTTPClient = httpClient
 	c.baseHTTPClient.Timeout = c.requestTimeout
@@ -154,10 +162,57 @@ func (c *Config) WithSkipTLSValidation(skip bool) {
 	c.setTLSConfigOnHTTPClient()
 }
 
+// WithCACertPool sets the http.Client underlying transport root CAs used to verify the CF API, UAA and
+// login server certificates
+func (c *Config) WithCACertPool(pool *x509.CertPool) {
+	c.caCertPool = pool
+	c.setTLSConfigOnHTTPClient()
+}
+
+// WithCACertFile adds the PEM encoded CA certificate(s) in the specified file to the set of trusted root CAs
+//
+// The certificates are added to the system cert pool, or any previously configured CA cert pool, so public
+// CAs continue to be trusted.
+func (c *Config) WithCACertFile(caCertFile string) error {
+	pem, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return fmt.Errorf("error reading CA cert file %s: %w", caCertFile, err)
+	}
+
+	pool := c.caCertPool
+	if pool == nil {
+		pool, err = x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+	} else {
+		pool = pool.Clone()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("expected CA cert file %s to contain at least one PEM encoded certificate", caCertFile)
+	}
+	c.WithCACertPool(pool)
+	return nil
+}
+
+// WithClientCertificate sets the PEM encoded client certificate and key the http.Client underlying transport
+// presents to servers that require mutual TLS
+func (c *Config) WithClientCertificate(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("error loading client certificate %s and key %s: %w", certFile, keyFile, err)
+	}
+	c.clientCerts = []tls.Certificate{cert}
+	c.setTLSConfigOnHTTPClient()
+	return nil
+}
+
 // WithRequestTimeout overrides the http.Client underlying transport request timeout
 func (c *Config) WithRequestTimeout(timeout time.Duration) {
 	c.requestTimeout = timeout
-	c.baseHTTPClient.Timeout = timeout
+	for _, hc := range c.httpClients() {
+		hc.Timeout = timeout
+	}
 }
 
 // HTTPClient returns the currently configured default base http.Client to be used as the base for all requests
@@ -175,9 +230,23 @@ func (c *Config) SkipTLSValidation() bool {
 	return c.skipTLSValidation
 }
 
+// CACertPool returns the currently configured http.Client underlying transport root CAs, nil if using the
+// system cert pool
+func (c *Config) CACertPool() *x509.CertPool {
+	return c.caCertPool
+}
+
+// ClientCertificates returns the currently configured http.Client underlying transport mutual TLS certificates
+func (c *Config) ClientCertificates() []tls.Certificate {
+	return c.clientCerts
+}
+
 func (c *Config) setNewDefaultHTTPClient() {
-	// use a copy of the default transport and it's settings
-	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// explicitly configure the transport's connection pool instead of relying on the global default transport
+	transport := NewTransport(c.transportOptions)
+	if c.proxy != nil {
+		transport.Proxy = c.proxy
+	}
 	c.baseHTTPClient = &http.Client{
 		Timeout:   c.requestTimeout,
 		Transport: transport,
@@ -185,23 +254,22 @@ func (c *Config) setNewDefaultHTTPClient() {
 }
 
 func (c *Config) setTLSConfigOnHTTPClient() {
-	// it's possible the consumer provided an oauth2.Transport instead of a http.Transport
-	var tp *http.Transport
-	switch t := c.baseHTTPClient.Transport.(type) {
-	case *http.Transport:
-		tp = t
-	case *oauth2.Transport:
-		if bt, ok := t.Base.(*http.Transport); ok {
-			tp = bt
+	for _, hc := range c.httpClients() {
+		// if we found a supported transport, set InsecureSkipVerify and any custom CAs or client certs
+		tp := findHTTPTransport(hc)
+		if tp == nil {
+			continue
 		}
-	}
-
-	// if we found a supported transport, set InsecureSkipVerify
-	if tp != nil {
 		if tp.TLSClientConfig == nil {
 			tp.TLSClientConfig = &tls.Config{}
 		}
 		tp.TLSClientConfig.InsecureSkipVerify = c.skipTLSValidation
+		if c.caCertPool != nil {
+			tp.TLSClientConfig.RootCAs = c.caCertPool
+		}
+		if len(c.clientCerts) > 0 {
+			tp.TLSClientConfig.Certificates = c.clientCerts
+		}
 	}
 }
 
@@ -216,6 +284,7 @@ func newDefault(apiRootURL string) (*Config, error) {
 		UserAgent:         UserAgent,
 		skipTLSValidation: false,
 		requestTimeout:    30 * time.Second,
+		transportOptions:  NewTransportOptions(),
 	}
 	c.setNewDefaultHTTPClient()
 	c.setTLSConfigOnHTTPClient()
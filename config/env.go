@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const (
+	EnvAPI               = "CF_API"
+	EnvUsername          = "CF_USERNAME"
+	EnvPassword          = "CF_PASSWORD"
+	EnvClientID          = "CF_CLIENT_ID"
+	EnvClientSecret      = "CF_CLIENT_SECRET"
+	EnvSkipSSLValidation = "CF_SKIP_SSL_VALIDATION"
+	EnvOrigin            = "CF_ORIGIN"
+)
+
+// NewFromEnv creates a new config from the standard CF_* environment variables
+//
+// CF_API is required along with exactly one auth method, either CF_USERNAME and CF_PASSWORD or
+// CF_CLIENT_ID and CF_CLIENT_SECRET. CF_ORIGIN and CF_SKIP_SSL_VALIDATION are optional.
+func NewFromEnv() (*Config, error) {
+	s := &settings{
+		API:          os.Getenv(EnvAPI),
+		Username:     os.Getenv(EnvUsername),
+		Password:     os.Getenv(EnvPassword),
+		ClientID:     os.Getenv(EnvClientID),
+		ClientSecret: os.Getenv(EnvClientSecret),
+		Origin:       os.Getenv(EnvOrigin),
+	}
+	if v := os.Getenv(EnvSkipSSLValidation); v != "" {
+		skip, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("expected %s to be a boolean, but got %s", EnvSkipSSLValidation, v)
+		}
+		s.SkipSSLValidation = skip
+	}
+
+	c, err := s.toConfig(settingNames{
+		API:          EnvAPI,
+		Username:     EnvUsername,
+		Password:     EnvPassword,
+		ClientID:     EnvClientID,
+		ClientSecret: EnvClientSecret,
+		Origin:       EnvOrigin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid CF environment config: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"sort"
+	"strings"
+)
+
+// foundationsFile is the YAML file format holding one or more named CF foundations, for example:
+//
+//	foundations:
+//	  prod:
+//	    api: https://api.sys.example.com
+//	    client_id: deployer
+//	    client_secret: ${PROD_CLIENT_SECRET}
+//	  dev:
+//	    api: https://api.sys.dev.example.com
+//	    username: admin
+//	    password: ${DEV_PASSWORD}
+//	    skip_ssl_validation: true
+type foundationsFile struct {
+	Foundations map[string]*settings `yaml:"foundations"`
+}
+
+// NewFromFile creates a new config from the named foundation in the specified YAML config file
+//
+// Each foundation requires an api and exactly one auth method, either username and password, client_id
+// and client_secret, or token. Any string value may reference an environment variable using ${ENV_VAR}
+// which is useful for keeping secrets out of the config file.
+func NewFromFile(path, foundationName string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CF config file %s: %w", path, err)
+	}
+
+	var f foundationsFile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err = dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("error parsing CF config file %s: %w", path, err)
+	}
+
+	s, ok := f.Foundations[foundationName]
+	if !ok || s == nil {
+		return nil, fmt.Errorf("foundation %s not found in CF config file %s, expected one of: %s",
+			foundationName, path, strings.Join(f.foundationNames(), ", "))
+	}
+	if err = s.expandEnvRefs(); err != nil {
+		return nil, fmt.Errorf("invalid foundation %s in CF config file %s: %w", foundationName, path, err)
+	}
+
+	key := func(name string) string {
+		return fmt.Sprintf("foundations.%s.%s", foundationName, name)
+	}
+	c, err := s.toConfig(settingNames{
+		API:          key("api"),
+		Username:     key("username"),
+		Password:     key("password"),
+		ClientID:     key("client_id"),
+		ClientSecret: key("client_secret"),
+		Token:        key("token"),
+		Origin:       key("origin"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid foundation %s in CF config file %s: %w", foundationName, path, err)
+	}
+	return c, nil
+}
+
+func (f *foundationsFile) foundationNames() []string {
+	var names []string
+	for name := range f.Foundations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// envRefRegex matches ${VAR} style environment variable references
+var envRefRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// settings holds the raw, un-validated values from an external config source like env vars or a config file
+type settings struct {
+	API               string `yaml:"api"`
+	Username          string `yaml:"username"`
+	Password          string `yaml:"password"`
+	ClientID          string `yaml:"client_id"`
+	ClientSecret      string `yaml:"client_secret"`
+	Token             string `yaml:"token"`
+	Origin            string `yaml:"origin"`
+	SkipSSLValidation bool   `yaml:"skip_ssl_validation"`
+}
+
+// settingNames holds the source specific names of each setting so validation errors can point to the
+// exact env var or config file key that's wrong
+type settingNames struct {
+	API          string
+	Username     string
+	Password     string
+	ClientID     string
+	ClientSecret string
+	Token        string // empty if the source doesn't support token auth
+	Origin       string
+}
+
+// toConfig validates the settings and creates a new config using the single configured auth method
+func (s *settings) toConfig(names settingNames) (*Config, error) {
+	if s.API == "" {
+		return nil, fmt.Errorf("%s is required", names.API)
+	}
+
+	hasUser := s.Username != "" || s.Password != ""
+	hasClient := s.ClientID != "" || s.ClientSecret != ""
+	hasToken := s.Token != ""
+
+	switch {
+	case hasUser && hasClient:
+		return nil, fmt.Errorf("%s/%s and %s/%s are mutually exclusive, only one auth method may be configured",
+			names.Username, names.Password, names.ClientID, names.ClientSecret)
+	case hasUser && hasToken:
+		return nil, fmt.Errorf("%s/%s and %s are mutually exclusive, only one auth method may be configured",
+			names.Username, names.Password, names.Token)
+	case hasClient && hasToken:
+		return nil, fmt.Errorf("%s/%s and %s are mutually exclusive, only one auth method may be configured",
+			names.ClientID, names.ClientSecret, names.Token)
+	case s.Origin != "" && !hasUser:
+		return nil, fmt.Errorf("%s is only supported with %s/%s auth", names.Origin, names.Username, names.Password)
+	}
+
+	var c *Config
+	var err error
+	switch {
+	case hasUser:
+		if s.Username == "" {
+			return nil, fmt.Errorf("%s is required when %s is set", names.Username, names.Password)
+		}
+		if s.Password == "" {
+			return nil, fmt.Errorf("%s is required when %s is set", names.Password, names.Username)
+		}
+		c, err = NewUserPassword(s.API, s.Username, s.Password)
+	case hasClient:
+		if s.ClientID == "" {
+			return nil, fmt.Errorf("%s is required when %s is set", names.ClientID, names.ClientSecret)
+		}
+		if s.ClientSecret == "" {
+			return nil, fmt.Errorf("%s is required when %s is set", names.ClientSecret, names.ClientID)
+		}
+		c, err = NewClientSecret(s.API, s.ClientID, s.ClientSecret)
+	case hasToken:
+		c, err = NewToken(s.API, s.Token)
+	case names.Token == "":
+		return nil, fmt.Errorf("no auth method configured, expected %s/%s or %s/%s",
+			names.Username, names.Password, names.ClientID, names.ClientSecret)
+	default:
+		return nil, fmt.Errorf("no auth method configured, expected %s/%s, %s/%s or %s",
+			names.Username, names.Password, names.ClientID, names.ClientSecret, names.Token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.Origin = s.Origin
+	c.WithSkipTLSValidation(s.SkipSSLValidation)
+	return c, nil
+}
+
+// expandEnvRefs replaces all ${VAR} references in the string fields with the env var value,
+// returning an error if a referenced env var isn't set
+func (s *settings) expandEnvRefs() error {
+	fields := []*string{
+		&s.API, &s.Username, &s.Password, &s.ClientID, &s.ClientSecret, &s.Token, &s.Origin,
+	}
+	for _, f := range fields {
+		v, err := expandEnvRefs(*f)
+		if err != nil {
+			return err
+		}
+		*f = v
+	}
+	return nil
+}
+
+func expandEnvRefs(s string) (string, error) {
+	var err error
+	expanded := envRefRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envRefRegex.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("referenced environment variable %s is not set", name)
+		}
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
@@ -21,8 +21,9 @@ type OAuthSessionManager struct {
 	oauthClient               *http.Client
 	oauthClientNonRedirecting *http.Client
 
-	tokenSource oauth2.TokenSource
-	mutex       *sync.RWMutex
+	tokenSource       oauth2.TokenSource
+	discoverEndpoints func(ctx context.Context) error
+	mutex             *sync.RWMutex
 }
 
 // NewOAuthSessionManager creates a new OAuth session manager
@@ -33,6 +34,18 @@ func NewOAuthSessionManager(config *config.Config) *OAuthSessionManager {
 	}
 }
 
+// WithEndpointDiscovery sets the func used to lazily discover the login and UAA endpoints on first use
+// when they aren't configured
+func (m *OAuthSessionManager) WithEndpointDiscovery(discover func(ctx context.Context) error) *OAuthSessionManager {
+	m.discoverEndpoints = discover
+	return m
+}
+
+// Authenticate initializes the auth context if it hasn't been initialized already
+func (m *OAuthSessionManager) Authenticate(ctx context.Context) error {
+	return m.init(ctx)
+}
+
 // Client returns an authenticated OAuth http client
 func (m *OAuthSessionManager) Client(followRedirects bool) (*http.Client, error) {
 	err := m.init(context.Background())
@@ -91,12 +104,23 @@ func (m *OAuthSessionManager) init(ctx context.Context) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	// another goroutine may have initialized the token source while we waited for the write lock
+	if m.tokenSource != nil {
+		return nil
+	}
+
 	// attempt to create a new token source
 	return m.newTokenSource(ctx)
 }
 
 // newTokenSource creates an appropriate OAuth token source based off the provided config
 func (m *OAuthSessionManager) newTokenSource(ctx context.Context) error {
+	if (m.config.LoginEndpointURL == "" || m.config.UAAEndpointURL == "") && m.discoverEndpoints != nil {
+		err := m.discoverEndpoints(ctx)
+		if err != nil {
+			return fmt.Errorf("error discovering login and UAA endpoints: %w", err)
+		}
+	}
 	if m.config.LoginEndpointURL == "" || m.config.UAAEndpointURL == "" {
 		return errors.New("login and UAA endpoints must not be empty")
 	}
@@ -104,24 +128,29 @@ func (m *OAuthSessionManager) newTokenSource(ctx context.Context) error {
 	loginEndpoint := path.Join(m.config.LoginEndpointURL, "/oauth/auth")
 	uaaEndpoint := path.Join(m.config.UAAEndpointURL, "/oauth/token")
 
-	// this provides the http.Client instance that the oauth subsystem will use for token acquisition
-	// and copy the base http transport from for new clients
-	oauthCtx := context.WithValue(ctx, oauth2.HTTPClient, m.config.HTTPClient())
+	// this provides the http.Client instance that the oauth subsystem will use for token acquisition, the
+	// token source is cached and refreshes tokens for the life of the client so it mustn't keep the caller's
+	// ctx which may be canceled as soon as the call that happened to initialize auth returns
+	uaaClient := m.config.EndpointHTTPClient(config.EndpointUAA)
+	tokenCtx := context.WithValue(context.Background(), oauth2.HTTPClient, uaaClient)
 
 	switch {
 	case m.config.Token != "":
-		m.userTokenAuth(oauthCtx, loginEndpoint, uaaEndpoint)
+		m.userTokenAuth(tokenCtx, loginEndpoint, uaaEndpoint)
 	case m.config.ClientID != "":
-		m.clientAuth(oauthCtx, uaaEndpoint)
+		m.clientAuth(tokenCtx, uaaEndpoint)
 	default:
-		return m.userAuth(oauthCtx, loginEndpoint, uaaEndpoint)
+		loginCtx := context.WithValue(ctx, oauth2.HTTPClient, uaaClient)
+		return m.userAuth(loginCtx, tokenCtx, loginEndpoint, uaaEndpoint)
 	}
 
 	return nil
 }
 
 // userAuth initializes a http client using standard username and password
-func (m *OAuthSessionManager) userAuth(ctx context.Context, loginEndpoint, uaaEndpoint string) error {
+//
+// The password is exchanged for a token using loginCtx while tokenCtx is kept by the token source for refreshes.
+func (m *OAuthSessionManager) userAuth(loginCtx, tokenCtx context.Context, loginEndpoint, uaaEndpoint string) error {
 	authConfig := &oauth2.Config{
 		ClientID: "cf",
 		Scopes:   []string{""},
@@ -144,13 +173,13 @@ func (m *OAuthSessionManager) userAuth(ctx context.Context, loginEndpoint, uaaEn
 		authConfig.Endpoint.TokenURL = path.Format("%s?%s", authConfig.Endpoint.TokenURL, val)
 	}
 
-	token, err := authConfig.PasswordCredentialsToken(ctx, m.config.Username, m.config.Password)
+	token, err := authConfig.PasswordCredentialsToken(loginCtx, m.config.Username, m.config.Password)
 	if err != nil {
 		return fmt.Errorf("error getting token for user auth: %w", err)
 	}
 
-	tokenSource := authConfig.TokenSource(ctx, token)
-	m.initOAuthClient(ctx, tokenSource)
+	tokenSource := authConfig.TokenSource(tokenCtx, token)
+	m.initOAuthClient(tokenSource)
 
 	return nil
 }
@@ -163,7 +192,7 @@ func (m *OAuthSessionManager) clientAuth(ctx context.Context, uaaEndpoint string
 		TokenURL:     uaaEndpoint,
 	}
 	tokenSource := authConfig.TokenSource(ctx)
-	m.initOAuthClient(ctx, tokenSource)
+	m.initOAuthClient(tokenSource)
 }
 
 // userTokenAuth initializes client credentials from existing bearer token.
@@ -183,25 +212,27 @@ func (m *OAuthSessionManager) userTokenAuth(ctx context.Context, loginEndpoint,
 		TokenType:   "Bearer",
 	}
 	tokenSource := authConfig.TokenSource(ctx, token)
-	m.initOAuthClient(ctx, tokenSource)
+	m.initOAuthClient(tokenSource)
 }
 
-func (m *OAuthSessionManager) initOAuthClient(ctx context.Context, tokenSource oauth2.TokenSource) {
-	bc := m.config.HTTPClient()
+func (m *OAuthSessionManager) initOAuthClient(tokenSource oauth2.TokenSource) {
+	bc := m.config.EndpointHTTPClient(config.EndpointAPI)
 
-	// oauth2.NewClient copies the underlying transport only, so explicitly copy other client values over
-	// without modifying the oauth2 client that was returned (since that's unsupported)
-	// https://github.com/golang/oauth2/issues/368
-	oac := oauth2.NewClient(ctx, tokenSource)
+	// wrap the API http transport instead of using oauth2.NewClient which would use the UAA http transport,
+	// and explicitly copy other client values over, see https://github.com/golang/oauth2/issues/368
+	oauthTransport := &oauth2.Transport{
+		Source: oauth2.ReuseTokenSource(nil, tokenSource),
+		Base:   bc.Transport,
+	}
 	oauthClient := &http.Client{
-		Transport:     oac.Transport,
+		Transport:     oauthTransport,
 		Timeout:       bc.Timeout,
 		Jar:           bc.Jar,
 		CheckRedirect: bc.CheckRedirect,
 	}
 
 	oauthClientNonRedirecting := &http.Client{
-		Transport: oac.Transport,
+		Transport: oauthTransport,
 		Timeout:   bc.Timeout,
 		Jar:       bc.Jar,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -6,6 +6,7 @@ import (
 	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/oauth2"
+	http2 "net/http"
 	"testing"
 )
 
@@ -69,3 +70,39 @@ func TestOAuthSessionManager(t *testing.T) {
 	require.Same(t, oauthTransport1.Base, oauthTransport2.Base, "expect the same http transport between Client() calls")
 	require.Same(t, c.HTTPClient().Transport, oauthTransport2.Base, "expect the same http transport from config")
 }
+
+func TestOAuthSessionManagerEndpointTransports(t *testing.T) {
+	uaaURL := testutil.SetupFakeUAAServer(300)
+	defer testutil.Teardown()
+
+	c, err := config.NewClientSecret("https://api.example.org", "opsman", "secret")
+	require.NoError(t, err)
+	c.LoginEndpointURL = uaaURL
+	c.UAAEndpointURL = uaaURL
+
+	uaaTransport := &countingTransport{base: config.NewTransport(nil)}
+	err = c.WithEndpointTransport(config.EndpointUAA, uaaTransport)
+	require.NoError(t, err)
+	m := http.NewOAuthSessionManager(c)
+
+	// the UAA transport is used to get tokens, while API requests use the base transport
+	token, err := m.AccessToken()
+	require.NoError(t, err)
+	require.Equal(t, "foobar1", token)
+	require.Equal(t, 1, uaaTransport.count)
+
+	client, err := m.Client(true)
+	require.NoError(t, err)
+	oauthTransport := client.Transport.(*oauth2.Transport)
+	require.Same(t, c.HTTPClient().Transport, oauthTransport.Base)
+}
+
+type countingTransport struct {
+	base  http2.RoundTripper
+	count int
+}
+
+func (t *countingTransport) RoundTrip(req *http2.Request) (*http2.Response, error) {
+	t.count++
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,357 @@
+package operation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/client"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"time"
+)
+
+// VenerableAppSuffix is appended to the name of the live app while a blue-green deployment replaces it
+const VenerableAppSuffix = "-venerable"
+
+// SmokeTestFunc verifies a newly pushed app before it's switched to the production routes, returning an error
+// rolls the deployment back
+type SmokeTestFunc func(ctx context.Context, app *resource.App) error
+
+// BlueGreenDeployOperation replaces a running app with a newly pushed copy without downtime, for apps that can't
+// use the rolling push strategy
+//
+// The live app is renamed with the venerable suffix and the new app is pushed under the original name without any
+// routes. Once all the new app's instances are running and the smoke test, if any, passes the new app is mapped to
+// the live app's routes and the venerable app is unmapped and then deleted or stopped. If anything fails before
+// the switch completes the new app is deleted and the venerable app is renamed back and keeps serving traffic.
+type BlueGreenDeployOperation struct {
+	pusher               *AppPushOperation
+	keepVenerable        bool
+	healthPollingOptions *client.PollingOptions
+	smokeTest            SmokeTestFunc
+}
+
+// NewBlueGreenDeployOperation creates a new BlueGreenDeployOperation that pushes the new app using the pusher
+func NewBlueGreenDeployOperation(pusher *AppPushOperation) *BlueGreenDeployOperation {
+	return &BlueGreenDeployOperation{
+		pusher: pusher,
+	}
+}
+
+// WithHealthPollingOptions sets the options used to wait for all the new app's instances to be running, if any
+// instance crashes or they aren't all running in time the deployment is rolled back
+func (o *BlueGreenDeployOperation) WithHealthPollingOptions(opts *client.PollingOptions) *BlueGreenDeployOperation {
+	o.healthPollingOptions = opts
+	return o
+}
+
+// WithKeepVenerable stops the venerable app instead of deleting it once the new app is live, so it can be used
+// to roll back manually. The stopped venerable app is deleted by the next deployment.
+func (o *BlueGreenDeployOperation) WithKeepVenerable(keep bool) *BlueGreenDeployOperation {
+	o.keepVenerable = keep
+	return o
+}
+
+// WithSmokeTest sets a test that's run against the new app after its instances are healthy
+//
+// The new app isn't mapped to any routes when the test runs, so the test must reach it some other way, for
+// example by mapping and later removing a test route of its own.
+func (o *BlueGreenDeployOperation) WithSmokeTest(smokeTest SmokeTestFunc) *BlueGreenDeployOperation {
+	o.smokeTest = smokeTest
+	return o
+}
+
+// Deploy pushes the app in the manifest from the source files and switches production traffic to it
+//
+// If the app doesn't exist yet it's simply pushed. Routes in the manifest aren't used when replacing an existing
+// app, the new app takes over all the routes of the live app instead.
+func (o *BlueGreenDeployOperation) Deploy(ctx context.Context, manifest *AppManifest, source *AppSource) (*resource.App, error) {
+	err := validateLifecycle(manifest)
+	if err != nil {
+		return nil, err
+	}
+	var upload packageUploader
+	if manifest.Docker == nil {
+		if source == nil {
+			return nil, fmt.Errorf("no source files specified for app %s", manifest.Name)
+		}
+		upload, err = source.uploader(o.pusher)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	space, err := o.pusher.findOrgSpace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live, err := o.pusher.findExistingApp(ctx, manifest.Name, space)
+	if err != nil {
+		return nil, err
+	}
+	if live == nil {
+		app, err := o.pusher.pushApp(ctx, space, manifest, upload)
+		if err != nil {
+			return nil, err
+		}
+		err = o.verify(ctx, app)
+		if err != nil {
+			return nil, err
+		}
+		return app, nil
+	}
+
+	routes, err := o.pusher.client.Routes.ListForAppAll(ctx, live.GUID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing routes for app %s: %w", live.Name, err)
+	}
+	venerable, err := o.renameLiveApp(ctx, space, live)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &blueGreenDeployment{
+		op:        o,
+		space:     space,
+		name:      manifest.Name,
+		venerable: venerable,
+		routes:    routes,
+	}
+	err = d.pushAndSwitch(ctx, space, manifest, upload)
+	if err != nil {
+		return nil, d.rollback(err)
+	}
+
+	if o.keepVenerable {
+		_, err = o.pusher.client.Applications.Stop(ctx, venerable.GUID)
+	} else {
+		err = o.deleteApp(ctx, venerable)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("app %s was deployed but cleaning up %s failed: %w", manifest.Name, venerable.Name, err)
+	}
+	return d.app, nil
+}
+
+// blueGreenDeployment tracks the progress of replacing the venerable app so a failure can be rolled back
+type blueGreenDeployment struct {
+	op        *BlueGreenDeployOperation
+	space     *resource.Space
+	name      string
+	venerable *resource.App
+	routes    []*resource.Route
+
+	app     *resource.App                           // the new app, nil until it's created
+	removed map[string][]*resource.RouteDestination // venerable destinations removed from each route GUID
+}
+
+// pushAndSwitch pushes and verifies the new app and then moves the venerable app's routes over to it
+func (d *blueGreenDeployment) pushAndSwitch(ctx context.Context, space *resource.Space, manifest *AppManifest, upload packageUploader) error {
+	// the new app gets the production routes only once it's verified
+	m := *manifest
+	m.Routes = nil
+	m.NoRoute = true
+	m.RandomRoute = false
+	m.DefaultRoute = false
+	p := d.op.pusher
+	err := p.applySpaceManifest(ctx, space, &Manifest{Applications: []*AppManifest{&m}})
+	if err != nil {
+		return err
+	}
+	// remember the app as soon as it exists so it's deleted if staging fails
+	d.app, err = p.findApp(ctx, m.Name, space)
+	if err != nil {
+		return err
+	}
+	app, err := p.stageApp(ctx, space, &m, upload, false)
+	if err != nil {
+		return err
+	}
+	d.app = app
+	err = d.op.verify(ctx, d.app)
+	if err != nil {
+		return err
+	}
+
+	// map the new app before unmapping the venerable app so the routes are always served
+	for _, route := range d.routes {
+		var dest []*resource.RouteDestinationInsertOrReplace
+		for _, v := range venerableDestinations(route, d.venerable) {
+			dest = append(dest, copyDestination(v, d.app.GUID))
+		}
+		if len(dest) == 0 {
+			continue
+		}
+		_, err = p.client.Routes.InsertDestinations(ctx, route.GUID, dest)
+		if err != nil {
+			return fmt.Errorf("error mapping route %s to app %s: %w", route.URL, d.app.Name, err)
+		}
+	}
+	d.removed = make(map[string][]*resource.RouteDestination)
+	for _, route := range d.routes {
+		for _, v := range venerableDestinations(route, d.venerable) {
+			err = p.client.Routes.RemoveDestination(ctx, route.GUID, *v.GUID)
+			if err != nil {
+				return fmt.Errorf("error unmapping route %s from app %s: %w", route.URL, d.venerable.Name, err)
+			}
+			d.removed[route.GUID] = append(d.removed[route.GUID], v)
+		}
+	}
+	return nil
+}
+
+// rollback deletes the new app and restores the venerable app's routes and name
+func (d *blueGreenDeployment) rollback(cause error) error {
+	// use a fresh context so a cancelled deployment still gets cleaned up
+	ctx := context.Background()
+	p := d.op.pusher
+	err := func() error {
+		for _, route := range d.routes {
+			var dest []*resource.RouteDestinationInsertOrReplace
+			for _, v := range d.removed[route.GUID] {
+				dest = append(dest, copyDestination(v, d.venerable.GUID))
+			}
+			if len(dest) == 0 {
+				continue
+			}
+			_, err := p.client.Routes.InsertDestinations(ctx, route.GUID, dest)
+			if err != nil {
+				return fmt.Errorf("error remapping route %s to app %s: %w", route.URL, d.venerable.Name, err)
+			}
+		}
+		app := d.app
+		if app == nil {
+			// applying the space manifest may have created the new app before failing on something else
+			var err error
+			app, err = p.findExistingApp(ctx, d.name, d.space)
+			if err != nil {
+				return fmt.Errorf("error finding new app %s: %w", d.name, err)
+			}
+		}
+		if app != nil {
+			err := d.op.deleteApp(ctx, app)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := p.client.Applications.Update(ctx, d.venerable.GUID, &resource.AppUpdate{Name: d.name})
+		if err != nil {
+			return fmt.Errorf("error renaming app %s back to %s: %w", d.venerable.Name, d.name, err)
+		}
+		return nil
+	}()
+	if err != nil {
+		return fmt.Errorf("blue-green deployment of app %s failed: %w, rolling back failed: %s", d.name, cause, err.Error())
+	}
+	return fmt.Errorf("blue-green deployment of app %s failed and was rolled back: %w", d.name, cause)
+}
+
+// renameLiveApp renames the live app to its venerable name, deleting any venerable app left by an earlier
+// deployment first
+func (o *BlueGreenDeployOperation) renameLiveApp(ctx context.Context, space *resource.Space, live *resource.App) (*resource.App, error) {
+	venerableName := live.Name + VenerableAppSuffix
+	old, err := o.pusher.findExistingApp(ctx, venerableName, space)
+	if err != nil {
+		return nil, err
+	}
+	if old != nil {
+		err = o.deleteApp(ctx, old)
+		if err != nil {
+			return nil, err
+		}
+	}
+	venerable, err := o.pusher.client.Applications.Update(ctx, live.GUID, &resource.AppUpdate{Name: venerableName})
+	if err != nil {
+		return nil, fmt.Errorf("error renaming app %s to %s: %w", live.Name, venerableName, err)
+	}
+	return venerable, nil
+}
+
+// verify waits for all the app's instances to be running and then runs the smoke test
+func (o *BlueGreenDeployOperation) verify(ctx context.Context, app *resource.App) error {
+	err := o.waitForHealthy(ctx, app)
+	if err != nil {
+		return err
+	}
+	if o.smokeTest == nil {
+		return nil
+	}
+	err = o.smokeTest(ctx, app)
+	if err != nil {
+		return fmt.Errorf("smoke test of app %s failed: %w", app.Name, err)
+	}
+	return nil
+}
+
+// waitForHealthy waits for every instance of every app process to be running, failing as soon as one crashes
+func (o *BlueGreenDeployOperation) waitForHealthy(ctx context.Context, app *resource.App) error {
+	processes, err := o.pusher.client.Processes.ListForAppAll(ctx, app.GUID, nil)
+	if err != nil {
+		return fmt.Errorf("error listing processes for app %s: %w", app.Name, err)
+	}
+
+	opts := client.NewPollingOptions()
+	if o.healthPollingOptions != nil {
+		*opts = *o.healthPollingOptions
+	}
+	opts.FailedState = resource.ProcessStateCrashed
+	err = client.PollForStateOrTimeoutWithContext(ctx, func() (string, error) {
+		stats := make([]*resource.ProcessStats, len(processes))
+		for i, process := range processes {
+			s, err := o.pusher.client.Processes.GetStats(ctx, process.GUID)
+			if err != nil {
+				return "", err
+			}
+			stats[i] = s
+		}
+		summary := resource.SummarizeProcessStats(stats...)
+		switch {
+		case summary.Crashed > 0:
+			return resource.ProcessStateCrashed, nil
+		case summary.Running == summary.Instances:
+			return resource.ProcessStateRunning, nil
+		}
+		return resource.ProcessStateStarting, nil
+	}, resource.ProcessStateRunning, opts)
+	switch {
+	case errors.Is(err, client.AsyncProcessFailedError):
+		return fmt.Errorf("app %s has crashed instances", app.Name)
+	case errors.Is(err, client.AsyncProcessTimeoutError):
+		return fmt.Errorf("app %s instances weren't all running after %s", app.Name, opts.Timeout.Round(time.Second))
+	case err != nil:
+		return fmt.Errorf("error checking app %s instances: %w", app.Name, err)
+	}
+	return nil
+}
+
+func (o *BlueGreenDeployOperation) deleteApp(ctx context.Context, app *resource.App) error {
+	jobGUID, err := o.pusher.client.Applications.Delete(ctx, app.GUID)
+	if err != nil {
+		return fmt.Errorf("error deleting app %s: %w", app.Name, err)
+	}
+	err = o.pusher.client.Jobs.PollComplete(ctx, jobGUID, nil)
+	if err != nil {
+		return fmt.Errorf("error waiting for app %s to be deleted: %w", app.Name, err)
+	}
+	return nil
+}
+
+// venerableDestinations returns the route's destinations that point at the app
+func venerableDestinations(route *resource.Route, app *resource.App) []*resource.RouteDestination {
+	var dest []*resource.RouteDestination
+	for i := range route.Destinations {
+		d := &route.Destinations[i]
+		if d.GUID != nil && d.App.GUID != nil && *d.App.GUID == app.GUID {
+			dest = append(dest, d)
+		}
+	}
+	return dest
+}
+
+// copyDestination returns a destination for the app with the same process type, port and protocol
+func copyDestination(d *resource.RouteDestination, appGUID string) *resource.RouteDestinationInsertOrReplace {
+	dest := resource.NewRouteDestinationInsertOrReplace(appGUID)
+	dest.App.Process = d.App.Process
+	dest.Port = d.Port
+	dest.Protocol = d.Protocol
+	return dest
+}
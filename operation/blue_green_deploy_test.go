@@ -0,0 +1,458 @@
+package operation_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/client"
+	"github.com/cloudfoundry-community/go-cfclient/v3/config"
+	"github.com/cloudfoundry-community/go-cfclient/v3/operation"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBlueGreenDeploy(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	job := g.Job("COMPLETE")
+	live := g.Application()
+	app := g.Application()
+	pkg := g.Package("READY")
+	build := g.Build("STAGED")
+	droplet := g.Droplet()
+	dropletAssoc := g.DropletAssociation()
+	process := g.Process()
+	route := g.Route()
+	// the route's first destination is the live app, the second belongs to another app and is left alone
+	routeJSON := strings.Replace(route.JSON, "0a6636b5-7fc4-44d8-8752-0db3e40b35a5", live.GUID, 1)
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			// the live app, no leftover venerable app, then the new app once the manifest is applied
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output: append(append(append(g.SinglePaged(live.JSON),
+				g.Paged([]string{})...),
+				g.SinglePaged(app.JSON)...),
+				g.SinglePaged(app.JSON)...),
+			Status: http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/routes", live.GUID),
+			Output:   g.SinglePaged(routeJSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPatch,
+			Endpoint: fmt.Sprintf("/v3/apps/%s", live.GUID),
+			Output:   g.Single(live.JSON),
+			Status:   http.StatusOK,
+			PostForm: fmt.Sprintf(`{ "name": "%s" }`, live.Name+"-venerable"),
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+		{
+			// the applied manifest and then the deleted app
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+			Output:   []string{job.JSON, job.JSON},
+			Status:   http.StatusOK,
+		},
+		{
+			// the new app is pushed with no-route
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/routes", app.GUID),
+			Output:   g.Paged([]string{}),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/packages",
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/upload", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/builds",
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/builds/%s", build.GUID),
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/droplets", pkg.GUID),
+			Output:   g.SinglePaged(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPatch,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", app.GUID),
+			Output:   g.Single(dropletAssoc.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/actions/start", app.GUID),
+			Output:   g.Single(app.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/processes", app.GUID),
+			Output:   g.SinglePaged(process.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/processes/%s/stats", process.GUID),
+			Output:   []string{strings.Replace(g.ProcessStats().JSON, "RUNNING", "STARTING", 1), g.ProcessStats().JSON},
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/routes/%s/destinations", route.GUID),
+			Output:   g.Single(g.RouteDestinations().JSON),
+			Status:   http.StatusOK,
+			PostForm: fmt.Sprintf(`{ "destinations": [
+				{ "app": { "guid": "%s", "process": { "type": "web" } }, "port": 8080, "protocol": "tcp" }
+			] }`, app.GUID),
+		},
+		{
+			Method:   http.MethodDelete,
+			Endpoint: fmt.Sprintf("/v3/routes/%s/destinations/385bf117-17f5-4689-8c5c-08c6cc821fed", route.GUID),
+			Status:   http.StatusNoContent,
+		},
+		{
+			Method:           http.MethodDelete,
+			Endpoint:         fmt.Sprintf("/v3/apps/%s", live.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	var smokeTested string
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name)
+	bg := operation.NewBlueGreenDeployOperation(pusher).
+		WithHealthPollingOptions(&client.PollingOptions{
+			Timeout:       time.Second,
+			CheckInterval: 10 * time.Millisecond,
+		}).
+		WithSmokeTest(func(ctx context.Context, app *resource.App) error {
+			smokeTested = app.GUID
+			return nil
+		})
+	deployed, err := bg.Deploy(context.Background(), &operation.AppManifest{Name: live.Name},
+		operation.NewZipAppSource(strings.NewReader("blah zip zip")))
+	require.NoError(t, err)
+	require.Equal(t, app.GUID, deployed.GUID)
+	require.Equal(t, app.GUID, smokeTested)
+}
+
+func TestBlueGreenDeployCrashRollback(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	job := g.Job("COMPLETE")
+	live := g.Application()
+	app := g.Application()
+	pkg := g.Package("READY")
+	build := g.Build("STAGED")
+	droplet := g.Droplet()
+	dropletAssoc := g.DropletAssociation()
+	process := g.Process()
+	route := g.Route()
+	// the route's first destination is the live app, the second belongs to another app and is left alone
+	routeJSON := strings.Replace(route.JSON, "0a6636b5-7fc4-44d8-8752-0db3e40b35a5", live.GUID, 1)
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			// the live app, no leftover venerable app, then the new app once the manifest is applied
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output: append(append(append(g.SinglePaged(live.JSON),
+				g.Paged([]string{})...),
+				g.SinglePaged(app.JSON)...),
+				g.SinglePaged(app.JSON)...),
+			Status: http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/routes", live.GUID),
+			Output:   g.SinglePaged(routeJSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPatch,
+			Endpoint: fmt.Sprintf("/v3/apps/%s", live.GUID),
+			Output:   g.Single(live.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+		{
+			// the applied manifest and then the deleted new app
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+			Output:   []string{job.JSON, job.JSON},
+			Status:   http.StatusOK,
+		},
+		{
+			// the new app is pushed with no-route
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/routes", app.GUID),
+			Output:   g.Paged([]string{}),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/packages",
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/upload", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/builds",
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/builds/%s", build.GUID),
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/droplets", pkg.GUID),
+			Output:   g.SinglePaged(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPatch,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", app.GUID),
+			Output:   g.Single(dropletAssoc.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/actions/start", app.GUID),
+			Output:   g.Single(app.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/processes", app.GUID),
+			Output:   g.SinglePaged(process.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/processes/%s/stats", process.GUID),
+			Output:   []string{strings.Replace(g.ProcessStats().JSON, "RUNNING", "CRASHED", 1)},
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodDelete,
+			Endpoint:         fmt.Sprintf("/v3/apps/%s", app.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name)
+	bg := operation.NewBlueGreenDeployOperation(pusher).
+		WithHealthPollingOptions(&client.PollingOptions{
+			Timeout:       time.Second,
+			CheckInterval: 10 * time.Millisecond,
+		}).
+		WithSmokeTest(func(ctx context.Context, app *resource.App) error {
+			require.Fail(t, "smoke test should not run when instances crash")
+			return nil
+		})
+	_, err = bg.Deploy(context.Background(), &operation.AppManifest{Name: live.Name},
+		operation.NewZipAppSource(strings.NewReader("blah zip zip")))
+	require.ErrorContains(t, err, "failed and was rolled back")
+	require.ErrorContains(t, err, "crashed instances")
+}
+
+func TestBlueGreenDeployManifestFailureRollback(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	failedJob := g.Job("FAILED")
+	deleteJob := g.Job("COMPLETE")
+	live := g.Application()
+	app := g.Application()
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			// the live app, no leftover venerable app, then the new app created by the failed manifest
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output: append(append(g.SinglePaged(live.JSON),
+				g.Paged([]string{})...),
+				g.SinglePaged(app.JSON)...),
+			Status: http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/routes", live.GUID),
+			Output:   g.Paged([]string{}),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPatch,
+			Endpoint: fmt.Sprintf("/v3/apps/%s", live.GUID),
+			Output:   g.Single(live.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			// the manifest creates the app and then fails, e.g. binding a service
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, failedJob.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", failedJob.GUID),
+			Output:   []string{failedJob.JSON, failedJob.JSON},
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodDelete,
+			Endpoint:         fmt.Sprintf("/v3/apps/%s", app.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, deleteJob.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", deleteJob.GUID),
+			Output:   g.Single(deleteJob.JSON),
+			Status:   http.StatusOK,
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	recorder := &recordingTransport{base: http.DefaultTransport}
+	require.NoError(t, c.WithEndpointTransport(config.EndpointAPI, recorder))
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name)
+	bg := operation.NewBlueGreenDeployOperation(pusher)
+	_, err = bg.Deploy(context.Background(), &operation.AppManifest{Name: live.Name},
+		operation.NewZipAppSource(strings.NewReader("blah zip zip")))
+	require.ErrorContains(t, err, "failed and was rolled back")
+	require.Contains(t, recorder.requests, fmt.Sprintf("DELETE /v3/apps/%s", app.GUID))
+}
+
+// recordingTransport records the method and path of every request
+type recordingTransport struct {
+	base     http.RoundTripper
+	requests []string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req.Method+" "+req.URL.Path)
+	return t.base.RoundTrip(req)
+}
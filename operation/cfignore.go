@@ -0,0 +1,102 @@
+package operation
+
+import (
+	"regexp"
+	"strings"
+)
+
+// defaultIgnorePatterns are always excluded from the app package, the same as the CF CLI
+var defaultIgnorePatterns = []string{
+	".cfignore",
+	"/manifest.yml",
+	".gitignore",
+	".git",
+	".hg",
+	".svn",
+	"_darcs",
+	".DS_Store",
+}
+
+// cfIgnore matches app file paths against .cfignore patterns
+//
+// The semantics are the same as the CF CLI: patterns starting with / are anchored to the app root, all other
+// patterns match at any depth, a pattern matching a directory also matches everything in it, patterns
+// starting with ! re-include previously excluded paths and the last matching pattern wins.
+type cfIgnore struct {
+	patterns    []ignorePattern
+	hasNegation bool
+}
+
+type ignorePattern struct {
+	exclude bool
+	re      *regexp.Regexp
+}
+
+// newCFIgnore parses the contents of a .cfignore file, the default patterns are always included
+func newCFIgnore(contents string) *cfIgnore {
+	ignore := &cfIgnore{}
+	lines := append(append([]string{}, defaultIgnorePatterns...), strings.Split(contents, "\n")...)
+	for _, line := range lines {
+		pattern := strings.TrimSpace(line)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		exclude := true
+		if strings.HasPrefix(pattern, "!") {
+			pattern = pattern[1:]
+			exclude = false
+			ignore.hasNegation = true
+		}
+		pattern = strings.TrimSuffix(pattern, "/")
+		if strings.HasPrefix(pattern, "/") {
+			pattern = pattern[1:]
+		} else {
+			pattern = "**/" + pattern
+		}
+		if pattern == "" {
+			continue
+		}
+
+		// match the path itself or anything beneath it
+		ignore.patterns = append(ignore.patterns, ignorePattern{
+			exclude: exclude,
+			re:      regexp.MustCompile("^" + globToRegexp(pattern) + "(/.*)?$"),
+		})
+	}
+	return ignore
+}
+
+// ShouldIgnore returns true if the slash separated path relative to the app root should be excluded
+func (c *cfIgnore) ShouldIgnore(path string) bool {
+	ignored := false
+	for _, p := range c.patterns {
+		if p.re.MatchString(path) {
+			ignored = p.exclude
+		}
+	}
+	return ignored
+}
+
+// globToRegexp converts a glob where * matches within a path segment and ** matches across segments
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,56 @@
+package operation
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCFIgnore(t *testing.T) {
+	ignore := newCFIgnore(`
+# comment
+/logs
+*.tmp
+node_modules/
+docs/**/*.md
+!important.tmp
+`)
+
+	tests := []struct {
+		path    string
+		ignored bool
+	}{
+		{".cfignore", true},
+		{".git", true},
+		{".git/HEAD", true},
+		{"sub/.git/HEAD", true},
+		{"manifest.yml", true},
+		{"sub/manifest.yml", false},
+		{"logs", true},
+		{"logs/app.log", true},
+		{"sub/logs/app.log", false},
+		{"a.tmp", true},
+		{"sub/dir/b.tmp", true},
+		{"important.tmp", false},
+		{"sub/important.tmp", false},
+		{"node_modules/express/index.js", true},
+		{"web/node_modules", true},
+		{"docs/readme.md", true},
+		{"docs/api/v3/readme.md", true},
+		{"readme.md", false},
+		{"src/main.go", false},
+		{"# comment", false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.ignored, ignore.ShouldIgnore(tt.path), tt.path)
+	}
+	require.True(t, ignore.hasNegation)
+}
+
+func TestCFIgnoreDefaults(t *testing.T) {
+	ignore := newCFIgnore("")
+	require.False(t, ignore.hasNegation)
+	require.True(t, ignore.ShouldIgnore(".DS_Store"))
+	require.True(t, ignore.ShouldIgnore("a/b/.svn/entries"))
+	require.False(t, ignore.ShouldIgnore("app.jar"))
+	require.False(t, ignore.ShouldIgnore(".gitkeep"))
+}
@@ -1,26 +1,43 @@
 package operation
 
+import "gopkg.in/yaml.v3"
+
 type Manifest struct {
+	Version      int            `yaml:"version,omitempty"`
 	Applications []*AppManifest `yaml:"applications"`
 }
 
 type AppManifest struct {
-	Name                    string              `yaml:"name"`
-	Buildpacks              []string            `yaml:"buildpacks,omitempty"`
-	Command                 string              `yaml:"command,omitempty"`
-	DiskQuota               string              `yaml:"disk_quota,omitempty"`
-	Docker                  *AppManifestDocker  `yaml:"docker,omitempty"`
-	Env                     map[string]string   `yaml:"env,omitempty"`
-	HealthCheckType         string              `yaml:"health-check-type,omitempty"`
-	HealthCheckHTTPEndpoint string              `yaml:"health-check-http-endpoint"` // required
-	Instances               int                 `yaml:"instances,omitempty"`
-	LogRateLimit            string              `yaml:"log-rate-limit,omitempty"`
-	Memory                  string              `yaml:"memory,omitempty"`
-	NoRoute                 bool                `yaml:"no-route,omitempty"`
-	Routes                  []AppManifestRoutes `yaml:"routes,omitempty"`
-	Services                []string            `yaml:"services,omitempty"`
-	Stack                   string              `yaml:"stack,omitempty"`
-	Timeout                 int                 `yaml:"timeout,omitempty"`
+	Name                                  string               `yaml:"name"`
+	Buildpacks                            []string             `yaml:"buildpacks,omitempty"`
+	Command                               string               `yaml:"command,omitempty"`
+	DefaultRoute                          bool                 `yaml:"default-route,omitempty"`
+	DiskQuota                             string               `yaml:"disk_quota,omitempty"`
+	Docker                                *AppManifestDocker   `yaml:"docker,omitempty"`
+	Env                                   map[string]string    `yaml:"env,omitempty"`
+	HealthCheckType                       string               `yaml:"health-check-type,omitempty"`
+	HealthCheckHTTPEndpoint               string               `yaml:"health-check-http-endpoint,omitempty"`
+	HealthCheckInterval                   int                  `yaml:"health-check-interval,omitempty"`
+	HealthCheckInvocationTimeout          int                  `yaml:"health-check-invocation-timeout,omitempty"`
+	Instances                             *int                 `yaml:"instances,omitempty"`      // a pointer so 0 instances is kept
+	Lifecycle                             string               `yaml:"lifecycle,omitempty"`      // buildpack (default), cnb or docker
+	LogRateLimit                          string               `yaml:"log-rate-limit,omitempty"` // Deprecated: use LogRateLimitPerSecond
+	LogRateLimitPerSecond                 string               `yaml:"log-rate-limit-per-second,omitempty"`
+	Memory                                string               `yaml:"memory,omitempty"`
+	Metadata                              *AppManifestMetadata `yaml:"metadata,omitempty"`
+	NoRoute                               bool                 `yaml:"no-route,omitempty"`
+	Path                                  string               `yaml:"path,omitempty"` // only used by the CF CLI, ignored by the API
+	Processes                             []AppManifestProcess `yaml:"processes,omitempty"`
+	RandomRoute                           bool                 `yaml:"random-route,omitempty"`
+	ReadinessHealthCheckType              string               `yaml:"readiness-health-check-type,omitempty"`
+	ReadinessHealthCheckHTTPEndpoint      string               `yaml:"readiness-health-check-http-endpoint,omitempty"`
+	ReadinessHealthCheckInterval          int                  `yaml:"readiness-health-check-interval,omitempty"`
+	ReadinessHealthCheckInvocationTimeout int                  `yaml:"readiness-health-check-invocation-timeout,omitempty"`
+	Routes                                []AppManifestRoutes  `yaml:"routes,omitempty"`
+	Services                              []AppManifestService `yaml:"services,omitempty"`
+	Sidecars                              []AppManifestSidecar `yaml:"sidecars,omitempty"`
+	Stack                                 string               `yaml:"stack,omitempty"`
+	Timeout                               int                  `yaml:"timeout,omitempty"`
 }
 
 type AppManifestDocker struct {
@@ -28,16 +45,84 @@ type AppManifestDocker struct {
 	Username string `yaml:"username,omitempty"`
 }
 
+type AppManifestMetadata struct {
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+}
+
+// AppManifestProcess overrides the app level settings for a single process type
+type AppManifestProcess struct {
+	Type                                  string `yaml:"type"`
+	Command                               string `yaml:"command,omitempty"`
+	DiskQuota                             string `yaml:"disk_quota,omitempty"`
+	HealthCheckType                       string `yaml:"health-check-type,omitempty"`
+	HealthCheckHTTPEndpoint               string `yaml:"health-check-http-endpoint,omitempty"`
+	HealthCheckInterval                   int    `yaml:"health-check-interval,omitempty"`
+	HealthCheckInvocationTimeout          int    `yaml:"health-check-invocation-timeout,omitempty"`
+	Instances                             *int   `yaml:"instances,omitempty"` // a pointer so 0 instances is kept
+	LogRateLimitPerSecond                 string `yaml:"log-rate-limit-per-second,omitempty"`
+	Memory                                string `yaml:"memory,omitempty"`
+	ReadinessHealthCheckType              string `yaml:"readiness-health-check-type,omitempty"`
+	ReadinessHealthCheckHTTPEndpoint      string `yaml:"readiness-health-check-http-endpoint,omitempty"`
+	ReadinessHealthCheckInterval          int    `yaml:"readiness-health-check-interval,omitempty"`
+	ReadinessHealthCheckInvocationTimeout int    `yaml:"readiness-health-check-invocation-timeout,omitempty"`
+	Timeout                               int    `yaml:"timeout,omitempty"`
+}
+
 type AppManifestRoutes struct {
-	Route string `yaml:"route,omitempty"`
+	Route    string `yaml:"route,omitempty"`
+	Protocol string `yaml:"protocol,omitempty"` // http1, http2 or tcp
+}
+
+// AppManifestService is a service instance to bind to the app
+//
+// In YAML a service is either just the service instance name, or a map with the name and the optional binding
+// name and parameters. Services with only a name are written in the short form.
+type AppManifestService struct {
+	Name        string         `yaml:"name"`
+	BindingName string         `yaml:"binding_name,omitempty"`
+	Parameters  map[string]any `yaml:"parameters,omitempty"`
+}
+
+// AppManifestSidecar is an additional process that runs in the same container as the app's processes
+type AppManifestSidecar struct {
+	Name         string   `yaml:"name"`
+	ProcessTypes []string `yaml:"process_types,omitempty"`
+	Command      string   `yaml:"command,omitempty"`
+	Memory       string   `yaml:"memory,omitempty"`
 }
 
 func NewAppManifest(appName string) *AppManifest {
+	instances := 1
 	return &AppManifest{
 		Name:                    appName,
 		HealthCheckType:         "port",
 		HealthCheckHTTPEndpoint: "/",
-		Instances:               1,
+		Instances:               &instances,
 		Memory:                  "256M",
 	}
 }
+
+// MarshalYAML writes the service as just its name when there's no binding name or parameters
+func (s AppManifestService) MarshalYAML() (interface{}, error) {
+	if s.BindingName == "" && len(s.Parameters) == 0 {
+		return s.Name, nil
+	}
+	type service AppManifestService
+	return service(s), nil
+}
+
+// UnmarshalYAML reads a service from either its name or a map with the name, binding name and parameters
+func (s *AppManifestService) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = AppManifestService{Name: node.Value}
+		return nil
+	}
+	type service AppManifestService
+	var svc service
+	if err := node.Decode(&svc); err != nil {
+		return err
+	}
+	*s = AppManifestService(svc)
+	return nil
+}
@@ -0,0 +1,65 @@
+package operation
+
+import (
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// FormatManifestDiff renders a manifest diff as a human-readable unified diff
+//
+// Each changed manifest path is rendered as a hunk with the previous value prefixed by - and the new value
+// prefixed by +, values that are maps or lists are rendered as YAML. When colorize is true the output contains
+// ANSI color codes suitable for terminals, otherwise the output is plain text suitable for a ```diff code
+// block in a PR comment.
+func FormatManifestDiff(diff *resource.ManifestDiff, colorize bool) string {
+	color := func(code, s string) string {
+		if !colorize {
+			return s
+		}
+		return code + s + ansiReset
+	}
+
+	if diff == nil || len(diff.Diff) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(color(ansiBold, "--- current") + "\n")
+	sb.WriteString(color(ansiBold, "+++ manifest") + "\n")
+	for _, item := range diff.Diff {
+		sb.WriteString(color(ansiCyan, fmt.Sprintf("@@ %s %s @@", item.Op, item.Path)) + "\n")
+		if item.Op == resource.ManifestDiffOpRemove || item.Op == resource.ManifestDiffOpReplace {
+			for _, line := range formatManifestDiffValue(item.Was) {
+				sb.WriteString(color(ansiRed, "-"+line) + "\n")
+			}
+		}
+		if item.Op == resource.ManifestDiffOpAdd || item.Op == resource.ManifestDiffOpReplace {
+			for _, line := range formatManifestDiffValue(item.Value) {
+				sb.WriteString(color(ansiGreen, "+"+line) + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// formatManifestDiffValue renders the value as YAML lines
+func formatManifestDiffValue(value any) []string {
+	if value == nil {
+		return nil
+	}
+	b, err := yaml.Marshal(value)
+	if err != nil {
+		return []string{fmt.Sprintf("%v", value)}
+	}
+	return strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+}
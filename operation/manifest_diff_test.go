@@ -0,0 +1,41 @@
+package operation
+
+import (
+	"encoding/json"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFormatManifestDiff(t *testing.T) {
+	g := testutil.NewObjectJSONGenerator(1)
+	var diff resource.ManifestDiff
+	err := json.Unmarshal([]byte(g.ManifestDiff().JSON), &diff)
+	require.NoError(t, err)
+
+	require.Equal(t, manifestDiffText, FormatManifestDiff(&diff, false))
+	require.Equal(t, manifestDiffColorText, FormatManifestDiff(&diff, true))
+	require.Empty(t, FormatManifestDiff(&resource.ManifestDiff{}, false))
+}
+
+const manifestDiffText = `--- current
++++ manifest
+@@ remove /applications/0/routes/1 @@
+-route: route.example.com
+@@ add /applications/1/buildpacks/2 @@
++java_buildpack
+@@ replace /applications/2/processes/1/memory @@
+-256M
++512M
+`
+
+const manifestDiffColorText = "\x1b[1m--- current\x1b[0m\n" +
+	"\x1b[1m+++ manifest\x1b[0m\n" +
+	"\x1b[36m@@ remove /applications/0/routes/1 @@\x1b[0m\n" +
+	"\x1b[31m-route: route.example.com\x1b[0m\n" +
+	"\x1b[36m@@ add /applications/1/buildpacks/2 @@\x1b[0m\n" +
+	"\x1b[32m+java_buildpack\x1b[0m\n" +
+	"\x1b[36m@@ replace /applications/2/processes/1/memory @@\x1b[0m\n" +
+	"\x1b[31m-256M\x1b[0m\n" +
+	"\x1b[32m+512M\x1b[0m\n"
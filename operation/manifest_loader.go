@@ -0,0 +1,208 @@
+package operation
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// manifestVarPattern matches a ((var)) placeholder, nested vars are separated by dots e.g. ((db.password))
+var manifestVarPattern = regexp.MustCompile(`\(\(([-/.\w\p{L}]+)\)\)`)
+
+// UnresolvedVarsError is returned when a manifest references variables that weren't provided
+type UnresolvedVarsError struct {
+	Names []string
+}
+
+func (e UnresolvedVarsError) Error() string {
+	return fmt.Sprintf("unresolved manifest variables: %s", strings.Join(e.Names, ", "))
+}
+
+// ManifestLoader reads application manifests and interpolates their ((var)) placeholders, the same as the
+// CF CLI's --vars-file and --var push flags
+type ManifestLoader struct {
+	varsFiles []string
+	vars      map[string]any
+}
+
+// NewManifestLoader creates a new ManifestLoader
+func NewManifestLoader() *ManifestLoader {
+	return &ManifestLoader{
+		vars: make(map[string]any),
+	}
+}
+
+// WithVarsFile adds a YAML file of variables, variables in later files override those in earlier files
+func (l *ManifestLoader) WithVarsFile(path string) *ManifestLoader {
+	l.varsFiles = append(l.varsFiles, path)
+	return l
+}
+
+// WithVars adds variables, these override variables from vars files
+func (l *ManifestLoader) WithVars(vars map[string]any) *ManifestLoader {
+	for k, v := range vars {
+		l.vars[k] = v
+	}
+	return l
+}
+
+// Load reads the manifest file and returns it with all variables interpolated, along with the rendered YAML
+// that can be passed to ApplyManifest
+func (l *ManifestLoader) Load(path string) (*Manifest, string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading manifest %s: %w", path, err)
+	}
+	return l.Interpolate(contents)
+}
+
+// Interpolate returns the manifest with all variables interpolated, along with the rendered YAML that can be
+// passed to ApplyManifest
+//
+// A placeholder that is the entire value is replaced by the variable's value, which can be any YAML type,
+// otherwise the placeholder is replaced within the string and the variable must be a scalar. An
+// UnresolvedVarsError listing every missing variable is returned if any variables weren't provided.
+func (l *ManifestLoader) Interpolate(manifest []byte) (*Manifest, string, error) {
+	vars, err := l.loadVars()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var doc yaml.Node
+	err = yaml.Unmarshal(manifest, &doc)
+	if err != nil {
+		return nil, "", fmt.Errorf("error parsing manifest: %w", err)
+	}
+	unresolved := make(map[string]bool)
+	err = interpolateNode(&doc, vars, unresolved)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(unresolved) > 0 {
+		names := make([]string, 0, len(unresolved))
+		for name := range unresolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, "", UnresolvedVarsError{Names: names}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err = enc.Encode(&doc)
+	if err != nil {
+		return nil, "", fmt.Errorf("error rendering manifest: %w", err)
+	}
+	err = enc.Close()
+	if err != nil {
+		return nil, "", fmt.Errorf("error rendering manifest: %w", err)
+	}
+
+	var m Manifest
+	err = yaml.Unmarshal(buf.Bytes(), &m)
+	if err != nil {
+		return nil, "", fmt.Errorf("error parsing interpolated manifest: %w", err)
+	}
+	return &m, buf.String(), nil
+}
+
+// loadVars merges the vars files, in order, with the explicitly set variables
+func (l *ManifestLoader) loadVars() (map[string]any, error) {
+	vars := make(map[string]any)
+	for _, path := range l.varsFiles {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading vars file %s: %w", path, err)
+		}
+		var fileVars map[string]any
+		err = yaml.Unmarshal(contents, &fileVars)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing vars file %s: %w", path, err)
+		}
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+	for k, v := range l.vars {
+		vars[k] = v
+	}
+	return vars, nil
+}
+
+// interpolateNode replaces the placeholders in all scalar nodes, recording any variables that weren't found
+func interpolateNode(node *yaml.Node, vars map[string]any, unresolved map[string]bool) error {
+	if node.Kind != yaml.ScalarNode {
+		for _, child := range node.Content {
+			err := interpolateNode(child, vars, unresolved)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !strings.Contains(node.Value, "((") {
+		return nil
+	}
+
+	// the whole value is a placeholder so keep the variable's type
+	if m := manifestVarPattern.FindStringSubmatch(node.Value); m != nil && m[0] == node.Value {
+		value, ok := lookupVar(vars, m[1])
+		if !ok {
+			unresolved[m[1]] = true
+			return nil
+		}
+		var replacement yaml.Node
+		err := replacement.Encode(value)
+		if err != nil {
+			return fmt.Errorf("error interpolating manifest variable %s: %w", m[1], err)
+		}
+		replacement.Line, replacement.Column = node.Line, node.Column
+		*node = replacement
+		return nil
+	}
+
+	var err error
+	node.Value = manifestVarPattern.ReplaceAllStringFunc(node.Value, func(placeholder string) string {
+		name := placeholder[2 : len(placeholder)-2]
+		value, ok := lookupVar(vars, name)
+		if !ok {
+			unresolved[name] = true
+			return placeholder
+		}
+		switch value.(type) {
+		case map[string]any, []any:
+			err = fmt.Errorf("manifest variable %s can't be interpolated into %q, it's not a string or number", name, node.Value)
+			return placeholder
+		}
+		return fmt.Sprint(value)
+	})
+	// the interpolated string may look like another type, e.g. a number, but must stay a string
+	node.Tag = "!!str"
+	node.Style = 0
+	return err
+}
+
+// lookupVar finds the variable by name, a name that isn't a variable itself is split on dots to look it up in
+// nested variables e.g. db.password
+func lookupVar(vars map[string]any, name string) (any, bool) {
+	if value, ok := vars[name]; ok {
+		return value, true
+	}
+	var current any = vars
+	for _, key := range strings.Split(name, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
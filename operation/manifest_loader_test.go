@@ -0,0 +1,98 @@
+package operation
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifestLoader(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.yml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(`---
+applications:
+- name: ((app_name))
+  instances: ((instances))
+  memory: ((memory_mb))M
+  buildpacks: ((buildpacks))
+  env:
+    DB_USER: ((db.user))
+    DB_PASSWORD: ((db.password))
+    PORT: ((port))
+    GREETING: hello ((app_name))
+  routes:
+  - route: ((app_name)).((domain))
+`), 0644))
+	varsPath := filepath.Join(dir, "vars.yml")
+	require.NoError(t, os.WriteFile(varsPath, []byte(`
+app_name: spring-music
+instances: 2
+memory_mb: 512
+buildpacks:
+- java_buildpack
+db:
+  user: admin
+  password: secret
+domain: apps.example.org
+`), 0644))
+	overridePath := filepath.Join(dir, "override.yml")
+	require.NoError(t, os.WriteFile(overridePath, []byte("instances: 3\nport: 8080\n"), 0644))
+
+	m, rendered, err := NewManifestLoader().
+		WithVarsFile(varsPath).
+		WithVarsFile(overridePath).
+		WithVars(map[string]any{"domain": "apps.internal"}).
+		Load(manifestPath)
+	require.NoError(t, err)
+
+	require.Len(t, m.Applications, 1)
+	app := m.Applications[0]
+	require.Equal(t, "spring-music", app.Name)
+	require.Equal(t, 3, *app.Instances)
+	require.Equal(t, "512M", app.Memory)
+	require.Equal(t, []string{"java_buildpack"}, app.Buildpacks)
+	require.Equal(t, map[string]string{
+		"DB_USER":     "admin",
+		"DB_PASSWORD": "secret",
+		"PORT":        "8080",
+		"GREETING":    "hello spring-music",
+	}, app.Env)
+	require.Equal(t, []AppManifestRoutes{{Route: "spring-music.apps.internal"}}, app.Routes)
+
+	require.Equal(t, `applications:
+  - name: spring-music
+    instances: 3
+    memory: 512M
+    buildpacks:
+      - java_buildpack
+    env:
+      DB_USER: admin
+      DB_PASSWORD: secret
+      PORT: 8080
+      GREETING: hello spring-music
+    routes:
+      - route: spring-music.apps.internal
+`, rendered)
+}
+
+func TestManifestLoaderErrors(t *testing.T) {
+	manifest := []byte(`applications:
+- name: ((app_name))
+  memory: ((memory))M
+  env:
+    A: ((missing.nested))
+    B: prefix-((app_name))-((other))
+`)
+	_, _, err := NewManifestLoader().Interpolate(manifest)
+	require.EqualError(t, err, "unresolved manifest variables: app_name, memory, missing.nested, other")
+	require.ErrorAs(t, err, &UnresolvedVarsError{})
+
+	_, _, err = NewManifestLoader().
+		WithVars(map[string]any{"app_name": "app", "memory": map[string]any{"size": 1}, "missing": map[string]any{"nested": "x"}, "other": "y"}).
+		Interpolate(manifest)
+	require.ErrorContains(t, err, "manifest variable memory can't be interpolated")
+
+	_, _, err = NewManifestLoader().WithVarsFile(filepath.Join(t.TempDir(), "missing.yml")).Interpolate(manifest)
+	require.ErrorContains(t, err, "error reading vars file")
+}
@@ -3,10 +3,12 @@ package operation
 import (
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
 	"testing"
 )
 
 func TestManifestMarshalling(t *testing.T) {
+	instances := 2
 	m := &Manifest{
 		Applications: []*AppManifest{
 			{
@@ -19,15 +21,15 @@ func TestManifestMarshalling(t *testing.T) {
 				},
 				HealthCheckType:         "http",
 				HealthCheckHTTPEndpoint: "/health",
-				Instances:               2,
+				Instances:               &instances,
 				LogRateLimit:            "100MB",
 				Memory:                  "1G",
 				NoRoute:                 false,
 				Routes: []AppManifestRoutes{
-					{"spring-music-egregious-porcupine-oa.apps.example.org"},
+					{Route: "spring-music-egregious-porcupine-oa.apps.example.org"},
 				},
-				Services: []string{
-					"my-sql",
+				Services: []AppManifestService{
+					{Name: "my-sql"},
 				},
 				Stack:   "cflinuxfs3",
 				Timeout: 60,
@@ -84,3 +86,95 @@ const minimalSpringMusicYaml = `applications:
   no-route: true
   stack: cflinuxfs3
 `
+
+func TestManifestRoundTrip(t *testing.T) {
+	var m Manifest
+	err := yaml3.Unmarshal([]byte(generatedManifestYaml), &m)
+	require.NoError(t, err)
+
+	app := m.Applications[0]
+	require.Equal(t, []AppManifestService{
+		{Name: "my-service1"},
+		{
+			Name:        "my-service-with-arbitrary-params",
+			BindingName: "my-binding",
+			Parameters:  map[string]any{"key1": "value1", "key2": 2},
+		},
+	}, app.Services)
+	require.Equal(t, AppManifestRoutes{Route: "another-route.example.com", Protocol: "http2"}, app.Routes[1])
+	require.Len(t, app.Processes, 2)
+	require.Equal(t, 0, *app.Instances)
+	require.Equal(t, 0, *app.Processes[1].Instances)
+	require.Equal(t, []string{"web", "worker"}, m.Applications[1].Sidecars[0].ProcessTypes)
+
+	b, err := yaml3.Marshal(&m)
+	require.NoError(t, err)
+	var expected, actual any
+	require.NoError(t, yaml3.Unmarshal([]byte(generatedManifestYaml), &expected))
+	require.NoError(t, yaml3.Unmarshal(b, &actual))
+	require.Equal(t, expected, actual)
+}
+
+// generatedManifestYaml is a manifest like the ones returned by ManifestClient.Generate
+const generatedManifestYaml = `---
+version: 1
+applications:
+  - name: app1
+    buildpacks:
+      - ruby_buildpack
+    default-route: true
+    env:
+      VAR1: value1
+    health-check-type: http
+    health-check-http-endpoint: /health
+    health-check-invocation-timeout: 5
+    instances: 0
+    lifecycle: buildpack
+    log-rate-limit-per-second: 1MB
+    metadata:
+      annotations:
+        contact: "bob@example.com jane@example.com"
+      labels:
+        sensitive: "true"
+    path: ./build
+    random-route: true
+    readiness-health-check-type: http
+    readiness-health-check-http-endpoint: /ready
+    readiness-health-check-invocation-timeout: 3
+    readiness-health-check-interval: 10
+    routes:
+      - route: route.example.com
+      - route: another-route.example.com
+        protocol: http2
+    services:
+      - my-service1
+      - name: my-service-with-arbitrary-params
+        binding_name: my-binding
+        parameters:
+          key1: value1
+          key2: 2
+    stack: cflinuxfs4
+    processes:
+      - type: web
+        command: start-web.sh
+        disk_quota: 512M
+        health-check-http-endpoint: /healthcheck
+        health-check-type: http
+        health-check-invocation-timeout: 10
+        instances: 3
+        memory: 500M
+        log-rate-limit-per-second: 1KB
+        readiness-health-check-type: port
+        timeout: 10
+      - type: worker
+        command: start-worker.sh
+        health-check-type: process
+        instances: 0
+        memory: 256M
+  - name: app2
+    sidecars:
+      - name: authenticator
+        process_types: [ 'web', 'worker' ]
+        command: bundle exec run-authenticator
+        memory: 800M
+`
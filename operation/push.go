@@ -2,40 +2,154 @@ package operation
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/cloudfoundry-community/go-cfclient/v3/client"
 	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
 	"gopkg.in/yaml.v3"
 	"io"
+	"os"
 )
 
-// AppPushOperation can be used to push buildpack apps
+// DockerPasswordEnvVar is the environment variable the docker registry password is read from when a password
+// isn't explicitly set, the same as the CF CLI
+const DockerPasswordEnvVar = "CF_DOCKER_PASSWORD"
+
+// resourceMatchBatchSize is the maximum number of resources sent in a single resource match request
+const resourceMatchBatchSize = 1000
+
+// PushStrategy is how an existing, running app is updated to the newly staged droplet
+type PushStrategy string
+
+const (
+	// PushStrategyNone replaces the droplet and restarts all instances at once, this is the default
+	PushStrategyNone PushStrategy = "none"
+
+	// PushStrategyRolling replaces the app instances one batch at a time without downtime
+	PushStrategyRolling PushStrategy = "rolling"
+
+	// PushStrategyCanary deploys a canary instance and pauses, the deployment must then be continued or canceled
+	PushStrategyCanary PushStrategy = "canary"
+)
+
+// AppPushOperation can be used to push buildpack, cloud native buildpack and docker apps
 type AppPushOperation struct {
-	orgName   string
-	spaceName string
-	client    *client.Client
+	orgName        string
+	spaceName      string
+	client         *client.Client
+	dockerPassword string
+
+	concurrency              int
+	dependencies             map[string][]string
+	strategy                 PushStrategy
+	deploymentOptions        *resource.DeploymentOptions
+	deploymentPollingOptions *client.PollingOptions
 }
 
 // NewAppPushOperation creates a new AppPushOperation
 func NewAppPushOperation(client *client.Client, orgName, spaceName string) *AppPushOperation {
 	return &AppPushOperation{
-		orgName:   orgName,
-		spaceName: spaceName,
-		client:    client,
+		orgName:     orgName,
+		spaceName:   spaceName,
+		client:      client,
+		concurrency: DefaultPushConcurrency,
+		strategy:    PushStrategyNone,
 	}
 }
 
+// WithConcurrency sets the maximum number of apps staged at the same time by PushManifest
+func (p *AppPushOperation) WithConcurrency(concurrency int) *AppPushOperation {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	p.concurrency = concurrency
+	return p
+}
+
+// WithDependencies sets the apps each app depends on by name, PushManifest only starts staging an app once all
+// the apps it depends on have been pushed successfully
+func (p *AppPushOperation) WithDependencies(dependencies map[string][]string) *AppPushOperation {
+	p.dependencies = dependencies
+	return p
+}
+
+// WithDeploymentOptions sets the options, like max in flight or the canary steps, of deployments created by the
+// rolling and canary strategies
+func (p *AppPushOperation) WithDeploymentOptions(opts *resource.DeploymentOptions) *AppPushOperation {
+	p.deploymentOptions = opts
+	return p
+}
+
+// WithDeploymentPollingOptions sets the options used to wait for deployments created by the rolling and canary
+// strategies, if the deployment doesn't finish in time it's rolled back
+func (p *AppPushOperation) WithDeploymentPollingOptions(opts *client.PollingOptions) *AppPushOperation {
+	p.deploymentPollingOptions = opts
+	return p
+}
+
+// WithDockerPassword sets the password used to pull the manifest's docker image from a private registry,
+// otherwise the password is read from the CF_DOCKER_PASSWORD environment variable
+func (p *AppPushOperation) WithDockerPassword(password string) *AppPushOperation {
+	p.dockerPassword = password
+	return p
+}
+
+// WithStrategy sets how an existing, running app is updated to the newly staged droplet
+//
+// With the rolling or canary strategy a deployment is created and Push waits for it to finish, or for a canary
+// deployment to pause. If the deployment fails or times out it's canceled, which rolls the app back to its
+// previous droplet. New or stopped apps are always just started.
+func (p *AppPushOperation) WithStrategy(strategy PushStrategy) *AppPushOperation {
+	p.strategy = strategy
+	return p
+}
+
 // Push creates or updates an application using the specified manifest and zipped source files
+//
+// If the manifest specifies a docker image the app is staged from the image instead and the zip file, which may
+// be nil, is ignored.
 func (p *AppPushOperation) Push(ctx context.Context, appManifest *AppManifest, zipFile io.Reader) (*resource.App, error) {
-	org, err := p.findOrg(ctx)
+	return p.pushSource(ctx, appManifest, NewZipAppSource(zipFile))
+}
+
+// PushDirectory creates or updates an application using the specified manifest and the source files in the
+// directory, see ZipDirectory for which files are included
+//
+// When the resource_matching feature flag is enabled only the files that Cloud Foundry doesn't already have
+// cached are uploaded, the rest are copied from the resource cache.
+func (p *AppPushOperation) PushDirectory(ctx context.Context, appManifest *AppManifest, dir string) (*resource.App, error) {
+	return p.pushSource(ctx, appManifest, NewDirectoryAppSource(dir))
+}
+
+func (p *AppPushOperation) pushSource(ctx context.Context, appManifest *AppManifest, source *AppSource) (*resource.App, error) {
+	var upload packageUploader
+	if appManifest.Docker == nil {
+		var err error
+		upload, err = source.uploader(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p.push(ctx, appManifest, upload)
+}
+
+// packageUploader uploads the app source bits to the newly created package
+type packageUploader func(ctx context.Context, pkg *resource.Package) error
+
+func (p *AppPushOperation) push(ctx context.Context, appManifest *AppManifest, upload packageUploader) (*resource.App, error) {
+	err := p.validateStrategy()
+	if err != nil {
+		return nil, err
+	}
+	err = validateLifecycle(appManifest)
 	if err != nil {
 		return nil, err
 	}
-	space, err := p.findSpace(ctx, org.GUID)
+	space, err := p.findOrgSpace(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return p.pushApp(ctx, space, appManifest, zipFile)
+	return p.pushApp(ctx, space, appManifest, upload)
 }
 
 // pushApp pushes an application
@@ -43,19 +157,42 @@ func (p *AppPushOperation) Push(ctx context.Context, appManifest *AppManifest, z
 // After an application is created and packages are uploaded, a droplet must be created via a build in order for
 // an application to be deployed or tasks to be run. The current droplet must be assigned to an application before
 // it may be started. When tasks are created, they either use a specific droplet guid, or use the current droplet
-// assigned to an application.
-func (p *AppPushOperation) pushApp(ctx context.Context, space *resource.Space, manifest *AppManifest, zipFile io.Reader) (*resource.App, error) {
-	err := p.applySpaceManifest(ctx, space, manifest)
+// assigned to an application. Running apps pushed with the rolling or canary strategy are instead updated to the
+// droplet via a deployment.
+func (p *AppPushOperation) pushApp(ctx context.Context, space *resource.Space, manifest *AppManifest, upload packageUploader) (*resource.App, error) {
+	deploy, err := p.shouldDeploy(ctx, manifest.Name, space)
 	if err != nil {
 		return nil, err
 	}
 
+	// wrap it in a manifest that has an applications array as required by the API
+	err = p.applySpaceManifest(ctx, space, &Manifest{
+		Applications: []*AppManifest{manifest},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.stageApp(ctx, space, manifest, upload, deploy)
+}
+
+// stageApp uploads and stages a new package for the app, whose manifest has already been applied, and then
+// starts or deploys the resulting droplet
+func (p *AppPushOperation) stageApp(ctx context.Context, space *resource.Space, manifest *AppManifest, upload packageUploader, deploy bool) (*resource.App, error) {
 	app, err := p.findApp(ctx, manifest.Name, space)
 	if err != nil {
 		return nil, err
 	}
+	err = p.updateRoutes(ctx, space, app, manifest)
+	if err != nil {
+		return nil, err
+	}
 
-	pkg, err := p.uploadPackage(ctx, app, zipFile)
+	var pkg *resource.Package
+	if manifest.Docker != nil {
+		pkg, err = p.createDockerPackage(ctx, app, manifest.Docker)
+	} else {
+		pkg, err = p.uploadPackage(ctx, app, upload)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +201,9 @@ func (p *AppPushOperation) pushApp(ctx context.Context, space *resource.Space, m
 	if err != nil {
 		return nil, err
 	}
+	if deploy {
+		return p.deployDroplet(ctx, app, droplet)
+	}
 
 	_, err = p.client.Droplets.SetCurrentAssociationForApp(ctx, app.GUID, droplet.GUID)
 	if err != nil {
@@ -73,12 +213,19 @@ func (p *AppPushOperation) pushApp(ctx context.Context, space *resource.Space, m
 	return p.client.Applications.Start(ctx, app.GUID)
 }
 
-func (p *AppPushOperation) applySpaceManifest(ctx context.Context, space *resource.Space, manifest *AppManifest) error {
-	// wrap it in a manifest that has an applications array as required by the API
-	multiAppsManifest := &Manifest{
-		Applications: []*AppManifest{manifest},
+func (p *AppPushOperation) applySpaceManifest(ctx context.Context, space *resource.Space, manifest *Manifest) error {
+	// random and default routes are created by the operation rather than the API, see updateRoutes
+	applied := &Manifest{
+		Version:      manifest.Version,
+		Applications: make([]*AppManifest, len(manifest.Applications)),
+	}
+	for i, appManifest := range manifest.Applications {
+		a := *appManifest
+		a.RandomRoute = false
+		a.DefaultRoute = false
+		applied.Applications[i] = &a
 	}
-	manifestBytes, err := yaml.Marshal(&multiAppsManifest)
+	manifestBytes, err := yaml.Marshal(applied)
 	if err != nil {
 		return fmt.Errorf("error marshalling application manifest: %w", err)
 	}
@@ -94,6 +241,19 @@ func (p *AppPushOperation) applySpaceManifest(ctx context.Context, space *resour
 	return nil
 }
 
+// shouldDeploy returns true if the app should be updated using a deployment, which is only the case for the
+// rolling and canary strategies when the app already exists and is running
+func (p *AppPushOperation) shouldDeploy(ctx context.Context, appName string, space *resource.Space) (bool, error) {
+	if p.strategy == PushStrategyNone {
+		return false, nil
+	}
+	existing, err := p.findExistingApp(ctx, appName, space)
+	if err != nil {
+		return false, err
+	}
+	return existing != nil && existing.State == "STARTED", nil
+}
+
 func (p *AppPushOperation) findApp(ctx context.Context, appName string, space *resource.Space) (*resource.App, error) {
 	appOpts := client.NewAppListOptions()
 	appOpts.Names.EqualTo(appName)
@@ -105,14 +265,23 @@ func (p *AppPushOperation) findApp(ctx context.Context, appName string, space *r
 	return app, nil
 }
 
-func (p *AppPushOperation) uploadPackage(ctx context.Context, app *resource.App, zipFile io.Reader) (*resource.Package, error) {
+// findExistingApp returns the app or nil if it doesn't exist yet
+func (p *AppPushOperation) findExistingApp(ctx context.Context, appName string, space *resource.Space) (*resource.App, error) {
+	app, err := p.findApp(ctx, appName, space)
+	if errors.Is(err, client.ErrExactlyOneResultNotReturned) {
+		return nil, nil
+	}
+	return app, err
+}
+
+func (p *AppPushOperation) uploadPackage(ctx context.Context, app *resource.App, upload packageUploader) (*resource.Package, error) {
 	newPkg := resource.NewPackageCreate(app.GUID)
 	pkg, err := p.client.Packages.Create(ctx, newPkg)
 	if err != nil {
 		return nil, fmt.Errorf("error creating package for app %s: %w", app.Name, err)
 	}
 
-	_, err = p.client.Packages.Upload(ctx, pkg.GUID, zipFile)
+	err = upload(ctx, pkg)
 	if err != nil {
 		return nil, fmt.Errorf("error uploading package bits for app %s: %w", app.Name, err)
 	}
@@ -123,15 +292,102 @@ func (p *AppPushOperation) uploadPackage(ctx context.Context, app *resource.App,
 	return pkg, nil
 }
 
+// createDockerPackage creates a docker package for the image, docker packages are ready to stage immediately
+func (p *AppPushOperation) createDockerPackage(ctx context.Context, app *resource.App, docker *AppManifestDocker) (*resource.Package, error) {
+	newPkg := resource.NewDockerPackageCreate(app.GUID, docker.Image, "", "")
+	if docker.Username != "" {
+		password := p.dockerPassword
+		if password == "" {
+			password = os.Getenv(DockerPasswordEnvVar)
+		}
+		if password == "" {
+			return nil, fmt.Errorf("docker password for user %s is required, set it on the push operation or via the %s environment variable",
+				docker.Username, DockerPasswordEnvVar)
+		}
+		newPkg = resource.NewDockerPackageCreate(app.GUID, docker.Image, docker.Username, password)
+	}
+
+	pkg, err := p.client.Packages.Create(ctx, newPkg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating docker package for app %s: %w", app.Name, err)
+	}
+	return pkg, nil
+}
+
+// uploadDirectory uploads the app directory files, using resource matching to skip the files Cloud Foundry
+// already has cached if the resource_matching feature flag is enabled
+func (p *AppPushOperation) uploadDirectory(ctx context.Context, pkg *resource.Package, appDir *appDirectory) error {
+	ff, err := p.client.FeatureFlags.Get(ctx, resource.FeatureFlagResourceMatching)
+	if err != nil {
+		return fmt.Errorf("error checking resource_matching feature flag: %w", err)
+	}
+	if !ff.Enabled {
+		zipFile := appDir.zip(nil)
+		defer func() {
+			_ = zipFile.Close()
+		}()
+		_, err = p.client.Packages.Upload(ctx, pkg.GUID, zipFile)
+		return err
+	}
+
+	resources, entries, err := appDir.fingerprint()
+	if err != nil {
+		return err
+	}
+	matched, err := p.matchResources(ctx, resources)
+	if err != nil {
+		return err
+	}
+	if entries == len(matched) {
+		// everything is cached so there's nothing to zip
+		_, err = p.client.Packages.UploadWithResources(ctx, pkg.GUID, nil, matched)
+		return err
+	}
+
+	skip := make(map[string]bool, len(matched))
+	for _, r := range matched {
+		skip[r.Path] = true
+	}
+	zipFile := appDir.zip(skip)
+	defer func() {
+		_ = zipFile.Close()
+	}()
+	_, err = p.client.Packages.UploadWithResources(ctx, pkg.GUID, zipFile, matched)
+	return err
+}
+
+// matchResources returns the resources that Cloud Foundry already has cached
+func (p *AppPushOperation) matchResources(ctx context.Context, resources []resource.ResourceMatch) ([]resource.ResourceMatch, error) {
+	local := make(map[string]resource.ResourceMatch, len(resources))
+	for _, r := range resources {
+		local[r.Path] = r
+	}
+
+	var matched []resource.ResourceMatch
+	for start := 0; start < len(resources); start += resourceMatchBatchSize {
+		end := start + resourceMatchBatchSize
+		if end > len(resources) {
+			end = len(resources)
+		}
+		m, err := p.client.ResourceMatches.Create(ctx, &resource.ResourceMatches{
+			Resources: resources[start:end],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error matching app resources: %w", err)
+		}
+		for _, r := range m.Resources {
+			// only trust matches for files we actually have
+			if l, ok := local[r.Path]; ok && l.Checksum == r.Checksum {
+				matched = append(matched, l)
+			}
+		}
+	}
+	return matched, nil
+}
+
 func (p *AppPushOperation) buildDroplet(ctx context.Context, pkg *resource.Package, manifest *AppManifest) (*resource.Droplet, error) {
 	newBuild := resource.NewBuildCreate(pkg.GUID)
-	newBuild.Lifecycle = &resource.Lifecycle{
-		Type: "buildpack",
-		BuildpackData: resource.BuildpackLifecycle{
-			Buildpacks: manifest.Buildpacks,
-			Stack:      manifest.Stack,
-		},
-	}
+	newBuild.Lifecycle = buildLifecycle(manifest)
 	build, err := p.client.Builds.Create(ctx, newBuild)
 	if err != nil {
 		return nil, fmt.Errorf("error creating build from package for app %s: %w", manifest.Name, err)
@@ -150,6 +406,66 @@ func (p *AppPushOperation) buildDroplet(ctx context.Context, pkg *resource.Packa
 	return droplet, nil
 }
 
+// deployDroplet updates the running app to the droplet using a deployment, canceling the deployment to roll back
+// if it fails
+func (p *AppPushOperation) deployDroplet(ctx context.Context, app *resource.App, droplet *resource.Droplet) (*resource.App, error) {
+	newDeployment := resource.NewDeploymentCreate(app.GUID)
+	newDeployment.Droplet = &resource.Relationship{GUID: droplet.GUID}
+	newDeployment.Strategy = resource.DeploymentStrategy(p.strategy)
+	newDeployment.Options = p.deploymentOptions
+	deployment, err := p.client.Deployments.Create(ctx, newDeployment)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s deployment for app %s: %w", p.strategy, app.Name, err)
+	}
+
+	if p.strategy == PushStrategyCanary {
+		err = p.client.Deployments.PollPaused(ctx, deployment.GUID, p.deploymentPollingOptions)
+	} else {
+		err = p.client.Deployments.PollDeployed(ctx, deployment.GUID, p.deploymentPollingOptions)
+	}
+	if err != nil {
+		err = p.rollbackDeployment(app, deployment, err)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p.client.Applications.Get(ctx, app.GUID)
+}
+
+// rollbackDeployment cancels the failed deployment, which reverts the app to its previous droplet
+//
+// A new context is used so the app is still rolled back if the deployment failed because the push was canceled.
+// Nil is returned if the deployment actually finished, e.g. just after polling timed out.
+func (p *AppPushOperation) rollbackDeployment(app *resource.App, deployment *resource.Deployment, cause error) error {
+	ctx := context.Background()
+	d, err := p.client.Deployments.Get(ctx, deployment.GUID)
+	if err != nil {
+		return fmt.Errorf("error waiting for deployment of app %s: %w, unable to get deployment status to roll back: %s",
+			app.Name, cause, err.Error())
+	}
+	if d.Status.IsDeployed() {
+		return nil
+	}
+	if !d.Status.IsActive() {
+		// already canceled or superseded so there's nothing to roll back
+		return fmt.Errorf("deployment of app %s failed: %w", app.Name, cause)
+	}
+
+	err = p.client.Deployments.Cancel(ctx, deployment.GUID)
+	if err != nil {
+		return fmt.Errorf("deployment of app %s failed: %w, rolling back failed: %s", app.Name, cause, err.Error())
+	}
+	return fmt.Errorf("deployment of app %s failed and was rolled back: %w", app.Name, cause)
+}
+
+func (p *AppPushOperation) findOrgSpace(ctx context.Context) (*resource.Space, error) {
+	org, err := p.findOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.findSpace(ctx, org.GUID)
+}
+
 func (p *AppPushOperation) findOrg(ctx context.Context) (*resource.Organization, error) {
 	opts := client.NewOrganizationListOptions()
 	opts.Names.EqualTo(p.orgName)
@@ -170,3 +486,57 @@ func (p *AppPushOperation) findSpace(ctx context.Context, orgGUID string) (*reso
 	}
 	return space, nil
 }
+
+func (p *AppPushOperation) validateStrategy() error {
+	switch p.strategy {
+	case PushStrategyNone, PushStrategyRolling, PushStrategyCanary:
+		return nil
+	}
+	return fmt.Errorf("unsupported push strategy %s", p.strategy)
+}
+
+// validateLifecycle checks the manifest's lifecycle is compatible with its docker and buildpack settings
+func validateLifecycle(manifest *AppManifest) error {
+	switch manifest.Lifecycle {
+	case "", resource.LifecycleBuildpack.String(), resource.LifecycleCNB.String(), resource.LifecycleDocker.String():
+	default:
+		return fmt.Errorf("app %s has unsupported lifecycle %s", manifest.Name, manifest.Lifecycle)
+	}
+
+	if manifest.Docker == nil {
+		if manifest.Lifecycle == resource.LifecycleDocker.String() {
+			return fmt.Errorf("app %s uses the docker lifecycle but doesn't specify a docker image", manifest.Name)
+		}
+		return nil
+	}
+	if manifest.Docker.Image == "" {
+		return fmt.Errorf("app %s docker image is required", manifest.Name)
+	}
+	if manifest.Lifecycle != "" && manifest.Lifecycle != resource.LifecycleDocker.String() {
+		return fmt.Errorf("app %s specifies a docker image which can't be used with the %s lifecycle", manifest.Name, manifest.Lifecycle)
+	}
+	if len(manifest.Buildpacks) > 0 {
+		return fmt.Errorf("app %s specifies a docker image which can't be used with buildpacks", manifest.Name)
+	}
+	return nil
+}
+
+// buildLifecycle returns the staging lifecycle for the manifest, the buildpack lifecycle is used by default
+func buildLifecycle(manifest *AppManifest) *resource.Lifecycle {
+	if manifest.Docker != nil {
+		return &resource.Lifecycle{
+			Type: resource.LifecycleDocker.String(),
+		}
+	}
+	lifecycleType := resource.LifecycleBuildpack.String()
+	if manifest.Lifecycle == resource.LifecycleCNB.String() {
+		lifecycleType = resource.LifecycleCNB.String()
+	}
+	return &resource.Lifecycle{
+		Type: lifecycleType,
+		BuildpackData: resource.BuildpackLifecycle{
+			Buildpacks: manifest.Buildpacks,
+			Stack:      manifest.Stack,
+		},
+	}
+}
@@ -0,0 +1,226 @@
+package operation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"io"
+	"strings"
+	"sync"
+)
+
+// DefaultPushConcurrency is the default number of apps staged at the same time by PushManifest
+const DefaultPushConcurrency = 3
+
+// ErrDependencyFailed is the error of an app that PushManifest skipped because an app it depends on wasn't pushed
+var ErrDependencyFailed = errors.New("dependency failed")
+
+// AppSource is where an app's package bits are read from, see NewZipAppSource and NewDirectoryAppSource
+type AppSource struct {
+	zipFile io.Reader
+	dir     string
+}
+
+// AppPushResult is the outcome of pushing a single app from a manifest
+type AppPushResult struct {
+	Name    string
+	App     *resource.App // nil if the push failed
+	Err     error
+	Skipped bool // true if the app wasn't staged because an app it depends on failed, Err wraps ErrDependencyFailed
+}
+
+// NewZipAppSource creates an AppSource from zipped source files
+func NewZipAppSource(zipFile io.Reader) *AppSource {
+	return &AppSource{
+		zipFile: zipFile,
+	}
+}
+
+// NewDirectoryAppSource creates an AppSource from the source files in a directory, see PushDirectory
+func NewDirectoryAppSource(dir string) *AppSource {
+	return &AppSource{
+		dir: dir,
+	}
+}
+
+// PushManifest creates or updates every application in the manifest
+//
+// The sources map each app name to its source files, docker apps don't need a source. The whole manifest is
+// applied at once and then the apps are staged and started in parallel, up to the operation's concurrency at a
+// time. Apps with dependencies, see WithDependencies, are only staged once all their dependencies have been
+// pushed, if a dependency fails the app and everything that depends on it are skipped. A result is returned for
+// each app in manifest order, so a failure pushing one app doesn't stop the others. An error is only returned
+// if the manifest is invalid or can't be applied, or the context is cancelled in which case apps not yet started
+// are left with the context error as their result.
+func (p *AppPushOperation) PushManifest(ctx context.Context, manifest *Manifest, sources map[string]*AppSource) ([]*AppPushResult, error) {
+	err := p.validateStrategy()
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Applications) == 0 {
+		return nil, errors.New("manifest doesn't contain any applications")
+	}
+
+	names := make(map[string]bool, len(manifest.Applications))
+	for _, appManifest := range manifest.Applications {
+		if names[appManifest.Name] {
+			return nil, fmt.Errorf("manifest contains app %s more than once", appManifest.Name)
+		}
+		names[appManifest.Name] = true
+		err = validateLifecycle(appManifest)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = p.validateDependencies(manifest)
+	if err != nil {
+		return nil, err
+	}
+	uploaders := make([]packageUploader, len(manifest.Applications))
+	for i, appManifest := range manifest.Applications {
+		if appManifest.Docker != nil {
+			continue
+		}
+		source, ok := sources[appManifest.Name]
+		if !ok || source == nil {
+			return nil, fmt.Errorf("no source files specified for app %s", appManifest.Name)
+		}
+		uploaders[i], err = source.uploader(p)
+		if err != nil {
+			return nil, fmt.Errorf("error reading app %s source: %w", appManifest.Name, err)
+		}
+	}
+
+	space, err := p.findOrgSpace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deploy := make([]bool, len(manifest.Applications))
+	for i, appManifest := range manifest.Applications {
+		deploy[i], err = p.shouldDeploy(ctx, appManifest.Name, space)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = p.applySpaceManifest(ctx, space, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*AppPushResult, len(manifest.Applications))
+	for i, appManifest := range manifest.Applications {
+		results[i] = &AppPushResult{Name: appManifest.Name}
+	}
+
+	index := make(map[string]int, len(manifest.Applications))
+	done := make([]chan struct{}, len(manifest.Applications))
+	for i, appManifest := range manifest.Applications {
+		index[appManifest.Name] = i
+		done[i] = make(chan struct{})
+	}
+
+	// each app waits for its dependencies before taking a slot so waiting apps never block the ones they need
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, p.concurrency)
+	for i, appManifest := range manifest.Applications {
+		wg.Add(1)
+		go func(i int, appManifest *AppManifest) {
+			defer func() {
+				close(done[i])
+				wg.Done()
+			}()
+			r := results[i]
+			for _, dep := range p.dependencies[appManifest.Name] {
+				j := index[dep]
+				select {
+				case <-ctx.Done():
+					r.Err = ctx.Err()
+					return
+				case <-done[j]:
+				}
+				if results[j].Err != nil {
+					r.Skipped = true
+					r.Err = fmt.Errorf("app %s wasn't pushed because app %s failed: %w", appManifest.Name, dep, ErrDependencyFailed)
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				r.Err = ctx.Err()
+				return
+			case sem <- struct{}{}:
+			}
+			defer func() {
+				<-sem
+			}()
+			r.App, r.Err = p.stageApp(ctx, space, appManifest, uploaders[i], deploy[i])
+		}(i, appManifest)
+	}
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// validateDependencies checks every dependency is an app in the manifest and that there are no cycles
+func (p *AppPushOperation) validateDependencies(manifest *Manifest) error {
+	names := make(map[string]bool, len(manifest.Applications))
+	for _, appManifest := range manifest.Applications {
+		names[appManifest.Name] = true
+	}
+	for name, deps := range p.dependencies {
+		for _, dep := range deps {
+			if names[name] && !names[dep] {
+				return fmt.Errorf("app %s depends on app %s which isn't in the manifest", name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(names))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("app dependencies contain a cycle: %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range p.dependencies[name] {
+			err := visit(dep, append(path, name))
+			if err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+	for _, appManifest := range manifest.Applications {
+		err := visit(appManifest.Name, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploader returns a packageUploader for the source files
+func (s *AppSource) uploader(p *AppPushOperation) (packageUploader, error) {
+	if s.dir == "" {
+		return func(ctx context.Context, pkg *resource.Package) error {
+			_, err := p.client.Packages.Upload(ctx, pkg.GUID, s.zipFile)
+			return err
+		}, nil
+	}
+	appDir, err := openAppDirectory(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, pkg *resource.Package) error {
+		return p.uploadDirectory(ctx, pkg, appDir)
+	}, nil
+}
@@ -0,0 +1,268 @@
+package operation_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/client"
+	"github.com/cloudfoundry-community/go-cfclient/v3/config"
+	"github.com/cloudfoundry-community/go-cfclient/v3/operation"
+	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAppPushManifest(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+	t.Setenv(operation.DockerPasswordEnvVar, "")
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	job := g.Job("COMPLETE")
+	web := g.Application()
+	worker := g.Application()
+	pkg := g.Package("READY")
+	build := g.Build("STAGED")
+	droplet := g.Droplet()
+	dropletAssoc := g.DropletAssociation()
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+			Output:   g.Single(job.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			// worker depends on web so web is always staged first
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output:   append(g.SinglePaged(web.JSON), g.SinglePaged(worker.JSON)...),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/packages",
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/upload", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/builds",
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/builds/%s", build.GUID),
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/droplets", pkg.GUID),
+			Output:   g.SinglePaged(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPatch,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", web.GUID),
+			Output:   g.Single(dropletAssoc.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/actions/start", web.GUID),
+			Output:   g.Single(web.JSON),
+			Status:   http.StatusOK,
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	manifest := &operation.Manifest{
+		Applications: []*operation.AppManifest{
+			{
+				Name: worker.Name,
+				Docker: &operation.AppManifestDocker{
+					Image:    "registry.example.org/worker:1.0",
+					Username: "deployer",
+				},
+			},
+			{
+				Name: web.Name,
+			},
+		},
+	}
+	sources := map[string]*operation.AppSource{
+		web.Name: operation.NewZipAppSource(strings.NewReader("blah zip zip")),
+	}
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name).
+		WithConcurrency(2).
+		WithDependencies(map[string][]string{worker.Name: {web.Name}})
+	results, err := pusher.PushManifest(context.Background(), manifest, sources)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.Equal(t, worker.Name, results[0].Name)
+	require.ErrorContains(t, results[0].Err, "docker password for user deployer is required")
+	require.False(t, results[0].Skipped)
+	require.Nil(t, results[0].App)
+
+	require.Equal(t, web.Name, results[1].Name)
+	require.NoError(t, results[1].Err)
+	require.Equal(t, web.GUID, results[1].App.GUID)
+}
+
+func TestAppPushManifestDependencyFailed(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+	t.Setenv(operation.DockerPasswordEnvVar, "")
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	job := g.Job("COMPLETE")
+	db := g.Application()
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+			Output:   g.Single(job.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			// only the failing db app is staged
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output:   g.SinglePaged(db.JSON),
+			Status:   http.StatusOK,
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	manifest := &operation.Manifest{
+		Applications: []*operation.AppManifest{
+			{Name: "web", Docker: &operation.AppManifestDocker{Image: "registry.example.org/web:1.0"}},
+			{Name: "api", Docker: &operation.AppManifestDocker{Image: "registry.example.org/api:1.0"}},
+			{Name: db.Name, Docker: &operation.AppManifestDocker{Image: "registry.example.org/db:1.0", Username: "deployer"}},
+		},
+	}
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name).
+		WithDependencies(map[string][]string{
+			"web": {"api"},
+			"api": {db.Name},
+		})
+	results, err := pusher.PushManifest(context.Background(), manifest, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	require.True(t, results[0].Skipped)
+	require.ErrorIs(t, results[0].Err, operation.ErrDependencyFailed)
+	require.ErrorContains(t, results[0].Err, "app api failed")
+	require.True(t, results[1].Skipped)
+	require.ErrorIs(t, results[1].Err, operation.ErrDependencyFailed)
+	require.False(t, results[2].Skipped)
+	require.ErrorContains(t, results[2].Err, "docker password for user deployer is required")
+}
+
+func TestAppPushManifestInvalid(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+	testutil.SetupMultiple(nil, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+	pusher := operation.NewAppPushOperation(cf, "org", "space")
+
+	_, err = pusher.PushManifest(context.Background(), &operation.Manifest{}, nil)
+	require.ErrorContains(t, err, "doesn't contain any applications")
+
+	manifest := &operation.Manifest{
+		Applications: []*operation.AppManifest{{Name: "web"}, {Name: "web"}},
+	}
+	_, err = pusher.PushManifest(context.Background(), manifest, nil)
+	require.ErrorContains(t, err, "contains app web more than once")
+
+	manifest = &operation.Manifest{
+		Applications: []*operation.AppManifest{{Name: "web"}, {Name: "worker"}},
+	}
+	sources := map[string]*operation.AppSource{
+		"web": operation.NewZipAppSource(strings.NewReader("blah zip zip")),
+	}
+	_, err = pusher.PushManifest(context.Background(), manifest, sources)
+	require.ErrorContains(t, err, "no source files specified for app worker")
+
+	sources["worker"] = operation.NewDirectoryAppSource(t.TempDir() + "/missing")
+	_, err = pusher.PushManifest(context.Background(), manifest, sources)
+	require.ErrorContains(t, err, "error reading app worker source")
+
+	sources["worker"] = operation.NewZipAppSource(strings.NewReader("blah zip zip"))
+	pusher.WithDependencies(map[string][]string{"web": {"db"}})
+	_, err = pusher.PushManifest(context.Background(), manifest, sources)
+	require.ErrorContains(t, err, "app web depends on app db which isn't in the manifest")
+
+	pusher.WithDependencies(map[string][]string{"web": {"worker"}, "worker": {"web"}})
+	_, err = pusher.PushManifest(context.Background(), manifest, sources)
+	require.ErrorContains(t, err, "app dependencies contain a cycle: web -> worker -> web")
+}
@@ -0,0 +1,191 @@
+package operation
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/client"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"math/big"
+	"strings"
+)
+
+const (
+	// maxRandomRouteAttempts is how many random hosts are tried before giving up because they're all taken
+	maxRandomRouteAttempts = 10
+
+	// maxRouteHostLength is the longest a DNS label, and so a route host, can be
+	maxRouteHostLength = 63
+)
+
+var (
+	randomRouteAdjectives = []string{
+		"brave", "calm", "clever", "eager", "fancy", "gentle", "happy", "jolly", "kind", "lively",
+		"lucky", "nimble", "proud", "quick", "quiet", "shiny", "silly", "sunny", "wise", "witty",
+	}
+	randomRouteNouns = []string{
+		"badger", "bear", "crane", "dolphin", "eagle", "falcon", "ferret", "fox", "gecko", "heron",
+		"koala", "lynx", "marmot", "otter", "owl", "panda", "puffin", "raven", "turtle", "walrus",
+	}
+)
+
+// updateRoutes makes the app's routes match the manifest's no-route, random-route and default-route settings
+//
+// With no-route the app is unmapped from all its routes. Otherwise, if the app has no routes and the manifest
+// doesn't list any, a route on the org's default domain is created and mapped to the app. The host is the app
+// name for default-route, or a random unused host for random-route.
+func (p *AppPushOperation) updateRoutes(ctx context.Context, space *resource.Space, app *resource.App, manifest *AppManifest) error {
+	if manifest.NoRoute {
+		return p.unmapRoutes(ctx, app)
+	}
+	if !manifest.RandomRoute && !manifest.DefaultRoute || len(manifest.Routes) > 0 {
+		return nil
+	}
+
+	routes, err := p.client.Routes.ListForAppAll(ctx, app.GUID, nil)
+	if err != nil {
+		return fmt.Errorf("error listing routes for app %s: %w", app.Name, err)
+	}
+	if len(routes) > 0 {
+		return nil
+	}
+
+	domain, err := p.client.Organizations.GetDefaultDomain(ctx, space.Relationships.Organization.Data.GUID)
+	if err != nil {
+		return fmt.Errorf("error getting default domain for app %s route: %w", app.Name, err)
+	}
+	var route *resource.Route
+	if manifest.RandomRoute {
+		route, err = p.createRandomRoute(ctx, space, domain, app.Name)
+	} else {
+		host := routeHost(app.Name, maxRouteHostLength)
+		if host == "" {
+			return fmt.Errorf("app name %s can't be used as a route host, use random-route or specify routes", app.Name)
+		}
+		route, err = p.findOrCreateRoute(ctx, space, domain, host)
+	}
+	if err != nil {
+		return fmt.Errorf("error creating route for app %s: %w", app.Name, err)
+	}
+
+	_, err = p.client.Routes.InsertDestinations(ctx, route.GUID, []*resource.RouteDestinationInsertOrReplace{
+		resource.NewRouteDestinationInsertOrReplace(app.GUID),
+	})
+	if err != nil {
+		return fmt.Errorf("error mapping route %s to app %s: %w", route.URL, app.Name, err)
+	}
+	return nil
+}
+
+// unmapRoutes removes the app from the destinations of all its routes, the routes themselves aren't deleted
+func (p *AppPushOperation) unmapRoutes(ctx context.Context, app *resource.App) error {
+	routes, err := p.client.Routes.ListForAppAll(ctx, app.GUID, nil)
+	if err != nil {
+		return fmt.Errorf("error listing routes for app %s: %w", app.Name, err)
+	}
+	for _, route := range routes {
+		for _, d := range route.Destinations {
+			if d.GUID == nil || d.App.GUID == nil || *d.App.GUID != app.GUID {
+				continue
+			}
+			err = p.client.Routes.RemoveDestination(ctx, route.GUID, *d.GUID)
+			if err != nil {
+				return fmt.Errorf("error unmapping route %s from app %s: %w", route.URL, app.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// createRandomRoute creates a route with a random host that isn't already taken
+func (p *AppPushOperation) createRandomRoute(ctx context.Context, space *resource.Space, domain *resource.Domain, appName string) (*resource.Route, error) {
+	for i := 0; i < maxRandomRouteAttempts; i++ {
+		host, err := randomRouteHost(appName)
+		if err != nil {
+			return nil, err
+		}
+		opts := client.NewRouteReservationListOptions()
+		opts.Hosts = host
+		reserved, err := p.client.Routes.IsRouteReserved(ctx, domain.GUID, opts)
+		if err != nil {
+			return nil, err
+		}
+		if !reserved {
+			return p.client.Routes.Create(ctx, newRouteCreate(domain.GUID, space.GUID, host))
+		}
+	}
+	return nil, fmt.Errorf("could not find an unused random host on domain %s after %d attempts", domain.Name, maxRandomRouteAttempts)
+}
+
+// findOrCreateRoute returns the route with the host if it already exists in the space, otherwise it's created
+func (p *AppPushOperation) findOrCreateRoute(ctx context.Context, space *resource.Space, domain *resource.Domain, host string) (*resource.Route, error) {
+	opts := client.NewRouteReservationListOptions()
+	opts.Hosts = host
+	reserved, err := p.client.Routes.IsRouteReserved(ctx, domain.GUID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		return p.client.Routes.Create(ctx, newRouteCreate(domain.GUID, space.GUID, host))
+	}
+
+	listOpts := client.NewRouteListOptions()
+	listOpts.Hosts.EqualTo(host)
+	listOpts.DomainGUIDs.EqualTo(domain.GUID)
+	listOpts.SpaceGUIDs.EqualTo(space.GUID)
+	routes, err := p.client.Routes.ListAll(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		if route.Path == "" {
+			return route, nil
+		}
+	}
+	return nil, fmt.Errorf("route %s.%s is already in use by another space", host, domain.Name)
+}
+
+func newRouteCreate(domainGUID, spaceGUID, host string) *resource.RouteCreate {
+	r := resource.NewRouteCreate(domainGUID, spaceGUID)
+	r.Host = &host
+	return r
+}
+
+// randomRouteHost returns a host like the CF CLI's random routes e.g. my-app-brave-otter-ab
+//
+// crypto/rand is used so the hosts aren't the same sequence in every process, which the unseeded math/rand source
+// is before Go 1.20.
+func randomRouteHost(appName string) (string, error) {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	var idx [4]int
+	for i, n := range []int{len(randomRouteAdjectives), len(randomRouteNouns), len(letters), len(letters)} {
+		r, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+		if err != nil {
+			return "", fmt.Errorf("error generating random route host: %w", err)
+		}
+		idx[i] = int(r.Int64())
+	}
+	suffix := fmt.Sprintf("%s-%s-%c%c",
+		randomRouteAdjectives[idx[0]], randomRouteNouns[idx[1]], letters[idx[2]], letters[idx[3]])
+	host := routeHost(appName, maxRouteHostLength-len(suffix)-1)
+	if host == "" {
+		return suffix, nil
+	}
+	return host + "-" + suffix, nil
+}
+
+// routeHost turns the app name into a valid host like the CF CLI does, by lowercasing it, replacing anything
+// other than letters, digits and hyphens with a hyphen, and trimming it to at most maxLen characters without
+// leading or trailing hyphens
+func routeHost(appName string, maxLen int) string {
+	host := []byte(strings.ToLower(appName))
+	for i, c := range host {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			host[i] = '-'
+		}
+	}
+	if len(host) > maxLen {
+		host = host[:maxLen]
+	}
+	return strings.Trim(string(host), "-")
+}
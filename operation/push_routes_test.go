@@ -0,0 +1,41 @@
+package operation
+
+import (
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRouteHost(t *testing.T) {
+	tests := []struct {
+		appName string
+		host    string
+	}{
+		{"spring-music", "spring-music"},
+		{"My_App", "my-app"},
+		{"api.v2", "api-v2"},
+		{"Billing_Service.V2", "billing-service-v2"},
+		{"_internal.", "internal"},
+		{"___", ""},
+		{strings.Repeat("a", 70), strings.Repeat("a", maxRouteHostLength)},
+		{strings.Repeat("a", 62) + "_b", strings.Repeat("a", 62)},
+	}
+	for _, tc := range tests {
+		require.Equal(t, tc.host, routeHost(tc.appName, maxRouteHostLength), tc.appName)
+	}
+}
+
+func TestRandomRouteHost(t *testing.T) {
+	valid := regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+	for _, appName := range []string{"My_App", "api.v2", strings.Repeat("Long_Name.", 10), "___"} {
+		host, err := randomRouteHost(appName)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(host), maxRouteHostLength, host)
+		require.Regexp(t, valid, host)
+	}
+
+	host, err := randomRouteHost("My_App")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(host, "my-app-"), host)
+}
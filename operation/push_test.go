@@ -2,15 +2,21 @@ package operation_test
 
 import (
 	"context"
+	"crypto/sha1"
 	"fmt"
 	"github.com/cloudfoundry-community/go-cfclient/v3/client"
 	"github.com/cloudfoundry-community/go-cfclient/v3/config"
 	"github.com/cloudfoundry-community/go-cfclient/v3/operation"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
 	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
 	"github.com/stretchr/testify/require"
+	"io/fs"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestAppPush(t *testing.T) {
@@ -28,19 +34,20 @@ func TestAppPush(t *testing.T) {
 	dropletAssoc := g.DropletAssociation()
 
 	fakeAppZipReader := strings.NewReader("blah zip zip")
+	instances := 2
 	manifest := &operation.AppManifest{
 		Name:                    app.Name,
 		Buildpacks:              []string{"java-buildpack-offline"},
 		HealthCheckType:         "http",
 		HealthCheckHTTPEndpoint: "/health",
-		Instances:               2,
+		Instances:               &instances,
 		Memory:                  "1G",
 		Routes: []operation.AppManifestRoutes{
 			{
 				Route: "https://spring-music.cf.apps.example.org",
 			},
 		},
-		Services: []string{"spring-music-sql"},
+		Services: []operation.AppManifestService{{Name: "spring-music-sql"}},
 		Stack:    "cflinuxfs3",
 	}
 
@@ -135,3 +142,1118 @@ func TestAppPush(t *testing.T) {
 	_, err = pusher.Push(context.Background(), manifest, fakeAppZipReader)
 	require.NoError(t, err)
 }
+
+func TestAppPushDirectory(t *testing.T) {
+	cachedSHA1 := fmt.Sprintf("%x", sha1.Sum([]byte("cached")))
+	newSHA1 := fmt.Sprintf("%x", sha1.Sum([]byte("new")))
+	cachedResource := fmt.Sprintf(`{"checksum":{"value":"%s"},"size_in_bytes":6,"path":"lib/big.jar","mode":"644"}`, cachedSHA1)
+	newResource := fmt.Sprintf(`{"checksum":{"value":"%s"},"size_in_bytes":3,"path":"app.js","mode":"755"}`, newSHA1)
+
+	tests := []struct {
+		description        string
+		files              map[string]string
+		featureFlagEnabled bool
+		expectedMatches    string
+		matched            string
+		expectedFields     map[string]string
+		expectedFiles      []string
+	}{
+		{
+			description:        "upload only unmatched files",
+			files:              map[string]string{"lib/big.jar": "cached", "app.js": "new"},
+			featureFlagEnabled: true,
+			expectedMatches:    fmt.Sprintf(`{"resources":[%s,%s]}`, newResource, cachedResource),
+			matched:            fmt.Sprintf(`{"resources":[%s]}`, cachedResource),
+			expectedFields:     map[string]string{"resources": fmt.Sprintf(`[%s]`, cachedResource)},
+			expectedFiles:      []string{"bits"},
+		},
+		{
+			description:        "all files matched",
+			files:              map[string]string{"big.jar": "cached"},
+			featureFlagEnabled: true,
+			expectedMatches:    fmt.Sprintf(`{"resources":[%s]}`, strings.ReplaceAll(cachedResource, "lib/", "")),
+			matched:            fmt.Sprintf(`{"resources":[%s]}`, strings.ReplaceAll(cachedResource, "lib/", "")),
+			expectedFields:     map[string]string{"resources": fmt.Sprintf(`[%s]`, strings.ReplaceAll(cachedResource, "lib/", ""))},
+		},
+		{
+			description:        "no files matched",
+			files:              map[string]string{"app.js": "new"},
+			featureFlagEnabled: true,
+			expectedMatches:    fmt.Sprintf(`{"resources":[%s]}`, newResource),
+			matched:            `{"resources":[]}`,
+			expectedFields:     map[string]string{"resources": `[]`},
+			expectedFiles:      []string{"bits"},
+		},
+		{
+			description:        "resource matching disabled",
+			files:              map[string]string{"lib/big.jar": "cached", "app.js": "new"},
+			featureFlagEnabled: false,
+			expectedFiles:      []string{"bits"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			serverURL := testutil.SetupFakeAPIServer()
+			defer testutil.Teardown()
+
+			g := testutil.NewObjectJSONGenerator(8723)
+			org := g.Organization()
+			space := g.Space()
+			job := g.Job("COMPLETE")
+			app := g.Application()
+			pkg := g.Package("READY")
+			build := g.Build("STAGED")
+			droplet := g.Droplet()
+			dropletAssoc := g.DropletAssociation()
+
+			dir := t.TempDir()
+			for name, contents := range tc.files {
+				mode := fs.FileMode(0644)
+				if strings.HasSuffix(name, ".js") {
+					mode = 0755
+				}
+				p := filepath.Join(dir, filepath.FromSlash(name))
+				require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+				require.NoError(t, os.WriteFile(p, []byte(contents), mode))
+				require.NoError(t, os.Chmod(p, mode))
+			}
+
+			routes := []testutil.MockRoute{
+				{
+					Method:   http.MethodGet,
+					Endpoint: "/v3/organizations",
+					Output:   g.SinglePaged(org.JSON),
+					Status:   http.StatusOK,
+				},
+				{
+					Method:   http.MethodGet,
+					Endpoint: "/v3/spaces",
+					Output:   g.SinglePaged(space.JSON),
+					Status:   http.StatusOK,
+				},
+				{
+					Method:           http.MethodPost,
+					Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+					Status:           http.StatusAccepted,
+					RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+				},
+				{
+					Method:   http.MethodGet,
+					Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+					Output:   g.Single(job.JSON),
+					Status:   http.StatusOK,
+				},
+				{
+					Method:   http.MethodGet,
+					Endpoint: "/v3/apps",
+					Output:   g.SinglePaged(app.JSON),
+					Status:   http.StatusOK,
+				},
+				{
+					Method:   http.MethodPost,
+					Endpoint: "/v3/packages",
+					Output:   g.Single(pkg.JSON),
+					Status:   http.StatusCreated,
+				},
+				{
+					Method:   http.MethodGet,
+					Endpoint: "/v3/feature_flags/resource_matching",
+					Output:   []string{fmt.Sprintf(`{"name":"resource_matching","enabled":%t}`, tc.featureFlagEnabled)},
+					Status:   http.StatusOK,
+				},
+				{
+					Method:   http.MethodPost,
+					Endpoint: "/v3/resource_matches",
+					Output:   []string{tc.matched},
+					Status:   http.StatusCreated,
+					PostForm: tc.expectedMatches,
+				},
+				{
+					Method:          "POST-FILE",
+					Endpoint:        fmt.Sprintf("/v3/packages/%s/upload", pkg.GUID),
+					Output:          g.Single(pkg.JSON),
+					Status:          http.StatusOK,
+					MultipartFields: tc.expectedFields,
+					MultipartFiles:  tc.expectedFiles,
+				},
+				{
+					Method:   http.MethodGet,
+					Endpoint: fmt.Sprintf("/v3/packages/%s", pkg.GUID),
+					Output:   g.Single(pkg.JSON),
+					Status:   http.StatusOK,
+				},
+				{
+					Method:   http.MethodPost,
+					Endpoint: "/v3/builds",
+					Output:   g.Single(build.JSON),
+					Status:   http.StatusCreated,
+				},
+				{
+					Method:   http.MethodGet,
+					Endpoint: fmt.Sprintf("/v3/builds/%s", build.GUID),
+					Output:   g.Single(build.JSON),
+					Status:   http.StatusOK,
+				},
+				{
+					Method:   http.MethodGet,
+					Endpoint: fmt.Sprintf("/v3/packages/%s/droplets", pkg.GUID),
+					Output:   g.SinglePaged(droplet.JSON),
+					Status:   http.StatusOK,
+				},
+				{
+					Method:   http.MethodPatch,
+					Endpoint: fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", app.GUID),
+					Output:   g.Single(dropletAssoc.JSON),
+					Status:   http.StatusOK,
+				},
+				{
+					Method:   http.MethodPost,
+					Endpoint: fmt.Sprintf("/v3/apps/%s/actions/start", app.GUID),
+					Output:   g.Single(app.JSON),
+					Status:   http.StatusOK,
+				},
+			}
+			testutil.SetupMultiple(routes, t)
+
+			c, _ := config.NewToken(serverURL, "foo")
+			cf, err := client.New(c)
+			require.NoError(t, err)
+
+			pusher := operation.NewAppPushOperation(cf, org.Name, space.Name)
+			manifest := &operation.AppManifest{Name: app.Name}
+			_, err = pusher.PushDirectory(context.Background(), manifest, dir)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAppPushDocker(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+	t.Setenv(operation.DockerPasswordEnvVar, "env-secret")
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	job := g.Job("COMPLETE")
+	app := g.Application()
+	pkg := g.Package("READY")
+	build := g.Build("STAGED")
+	droplet := g.Droplet()
+	dropletAssoc := g.DropletAssociation()
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+			Output:   g.Single(job.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output:   g.SinglePaged(app.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/packages",
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusCreated,
+			PostForm: fmt.Sprintf(`{
+				"type": "docker",
+				"relationships": { "app": { "data": { "guid": "%s" } } },
+				"data": { "image": "registry.example.org/app:1.0", "username": "deployer", "password": "env-secret" }
+			}`, app.GUID),
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/builds",
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusCreated,
+			PostForm: fmt.Sprintf(`{
+				"package": { "guid": "%s" },
+				"lifecycle": { "type": "docker", "data": {} }
+			}`, pkg.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/builds/%s", build.GUID),
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/droplets", pkg.GUID),
+			Output:   g.SinglePaged(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPatch,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", app.GUID),
+			Output:   g.Single(dropletAssoc.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/actions/start", app.GUID),
+			Output:   g.Single(app.JSON),
+			Status:   http.StatusOK,
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	manifest := &operation.AppManifest{
+		Name: app.Name,
+		Docker: &operation.AppManifestDocker{
+			Image:    "registry.example.org/app:1.0",
+			Username: "deployer",
+		},
+	}
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name)
+	_, err = pusher.Push(context.Background(), manifest, nil)
+	require.NoError(t, err)
+}
+
+func TestAppPushCNB(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	job := g.Job("COMPLETE")
+	app := g.Application()
+	pkg := g.Package("READY")
+	build := g.Build("STAGED")
+	droplet := g.Droplet()
+	dropletAssoc := g.DropletAssociation()
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+			Output:   g.Single(job.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output:   g.SinglePaged(app.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/packages",
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/upload", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/builds",
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusCreated,
+			PostForm: fmt.Sprintf(`{
+				"package": { "guid": "%s" },
+				"lifecycle": { "type": "cnb", "data": { "buildpacks": ["docker://paketobuildpacks/nodejs"], "stack": "cflinuxfs4" } }
+			}`, pkg.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/builds/%s", build.GUID),
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/droplets", pkg.GUID),
+			Output:   g.SinglePaged(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPatch,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", app.GUID),
+			Output:   g.Single(dropletAssoc.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/actions/start", app.GUID),
+			Output:   g.Single(app.JSON),
+			Status:   http.StatusOK,
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	manifest := &operation.AppManifest{
+		Name:       app.Name,
+		Lifecycle:  "cnb",
+		Buildpacks: []string{"docker://paketobuildpacks/nodejs"},
+		Stack:      "cflinuxfs4",
+	}
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name)
+	_, err = pusher.Push(context.Background(), manifest, strings.NewReader("blah zip zip"))
+	require.NoError(t, err)
+}
+
+func TestAppPushInvalidLifecycle(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+	testutil.SetupMultiple(nil, t)
+	t.Setenv(operation.DockerPasswordEnvVar, "")
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+	pusher := operation.NewAppPushOperation(cf, "org", "space")
+
+	tests := []struct {
+		manifest *operation.AppManifest
+		err      string
+	}{
+		{
+			manifest: &operation.AppManifest{Name: "app", Lifecycle: "kpack"},
+			err:      "unsupported lifecycle kpack",
+		},
+		{
+			manifest: &operation.AppManifest{Name: "app", Lifecycle: "docker"},
+			err:      "doesn't specify a docker image",
+		},
+		{
+			manifest: &operation.AppManifest{Name: "app", Docker: &operation.AppManifestDocker{}},
+			err:      "docker image is required",
+		},
+		{
+			manifest: &operation.AppManifest{Name: "app", Lifecycle: "cnb", Docker: &operation.AppManifestDocker{Image: "nginx"}},
+			err:      "can't be used with the cnb lifecycle",
+		},
+		{
+			manifest: &operation.AppManifest{Name: "app", Buildpacks: []string{"go_buildpack"}, Docker: &operation.AppManifestDocker{Image: "nginx"}},
+			err:      "can't be used with buildpacks",
+		},
+	}
+	for _, tc := range tests {
+		_, err := pusher.Push(context.Background(), tc.manifest, nil)
+		require.ErrorContains(t, err, tc.err)
+	}
+}
+
+func TestAppPushRolling(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	job := g.Job("COMPLETE")
+	app := g.Application()
+	pkg := g.Package("READY")
+	build := g.Build("STAGED")
+	droplet := g.Droplet()
+	deployment := g.Deployment()
+	deployed := g.DeploymentWithStatus("FINALIZED", "DEPLOYED")
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+			Output:   g.Single(job.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			// the running app the deployment updates, then the app after the manifest is applied
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output:   append(g.SinglePaged(app.JSON), g.SinglePaged(app.JSON)...),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/packages",
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/upload", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/builds",
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/builds/%s", build.GUID),
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/droplets", pkg.GUID),
+			Output:   g.SinglePaged(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/deployments",
+			Output:   g.Single(deployment.JSON),
+			Status:   http.StatusCreated,
+			PostForm: fmt.Sprintf(`{
+				"relationships": { "app": { "data": { "guid": "%s" } } },
+				"droplet": { "guid": "%s" },
+				"strategy": "rolling",
+				"options": { "max_in_flight": 2 }
+			}`, app.GUID, droplet.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/deployments/%s", deployment.GUID),
+			Output:   []string{deployment.JSON, deployed.JSON},
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s", app.GUID),
+			Output:   g.Single(app.JSON),
+			Status:   http.StatusOK,
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	maxInFlight := 2
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name).
+		WithStrategy(operation.PushStrategyRolling).
+		WithDeploymentOptions(&resource.DeploymentOptions{MaxInFlight: &maxInFlight}).
+		WithDeploymentPollingOptions(&client.PollingOptions{
+			Timeout:       time.Second,
+			CheckInterval: 10 * time.Millisecond,
+			FailedState:   "FAILED",
+		})
+	pushed, err := pusher.Push(context.Background(), &operation.AppManifest{Name: app.Name}, strings.NewReader("blah zip zip"))
+	require.NoError(t, err)
+	require.Equal(t, app.GUID, pushed.GUID)
+}
+
+func TestAppPushRollingRollback(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	job := g.Job("COMPLETE")
+	app := g.Application()
+	pkg := g.Package("READY")
+	build := g.Build("STAGED")
+	droplet := g.Droplet()
+	deployment := g.Deployment()
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+			Output:   g.Single(job.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			// the running app the deployment updates, then the app after the manifest is applied
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output:   append(g.SinglePaged(app.JSON), g.SinglePaged(app.JSON)...),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/packages",
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/upload", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/builds",
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/builds/%s", build.GUID),
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/droplets", pkg.GUID),
+			Output:   g.SinglePaged(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/deployments",
+			Output:   g.Single(deployment.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/deployments/%s", deployment.GUID),
+			Output:   []string{deployment.JSON, deployment.JSON, deployment.JSON, deployment.JSON, deployment.JSON},
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/deployments/%s/actions/cancel", deployment.GUID),
+			Status:   http.StatusOK,
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name).
+		WithStrategy(operation.PushStrategyRolling).
+		WithDeploymentPollingOptions(&client.PollingOptions{
+			Timeout:       50 * time.Millisecond,
+			CheckInterval: 20 * time.Millisecond,
+			FailedState:   "FAILED",
+		})
+	_, err = pusher.Push(context.Background(), &operation.AppManifest{Name: app.Name}, strings.NewReader("blah zip zip"))
+	require.ErrorContains(t, err, "was rolled back")
+	require.ErrorIs(t, err, client.AsyncProcessTimeoutError)
+}
+
+func TestAppPushRandomRoute(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	job := g.Job("COMPLETE")
+	app := g.Application()
+	pkg := g.Package("READY")
+	build := g.Build("STAGED")
+	droplet := g.Droplet()
+	dropletAssoc := g.DropletAssociation()
+	domain := g.Domain()
+	route := g.Route()
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+			Output:   g.Single(job.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output:   g.SinglePaged(app.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/routes", app.GUID),
+			Output:   g.Paged([]string{}),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations/e00705b9-7b42-4561-ae97-2520399d2133/domains/default",
+			Output:   g.Single(domain.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			// the first random host collides so another is tried
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/domains/%s/route_reservations", domain.GUID),
+			Output:   []string{`{ "matching_route": true }`, `{ "matching_route": false }`},
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/routes",
+			Output:   g.Single(route.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/routes/%s/destinations", route.GUID),
+			Output:   g.Single(g.RouteDestinations().JSON),
+			Status:   http.StatusOK,
+			PostForm: fmt.Sprintf(`{ "destinations": [ { "app": { "guid": "%s" } } ] }`, app.GUID),
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/packages",
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/upload", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/builds",
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/builds/%s", build.GUID),
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/droplets", pkg.GUID),
+			Output:   g.SinglePaged(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPatch,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", app.GUID),
+			Output:   g.Single(dropletAssoc.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/actions/start", app.GUID),
+			Output:   g.Single(app.JSON),
+			Status:   http.StatusOK,
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	manifest := &operation.AppManifest{
+		Name:        app.Name,
+		RandomRoute: true,
+	}
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name)
+	_, err = pusher.Push(context.Background(), manifest, strings.NewReader("blah zip zip"))
+	require.NoError(t, err)
+}
+
+func TestAppPushDefaultRoute(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	job := g.Job("COMPLETE")
+	app := g.Application()
+	pkg := g.Package("READY")
+	build := g.Build("STAGED")
+	droplet := g.Droplet()
+	dropletAssoc := g.DropletAssociation()
+	domain := g.Domain()
+	route := g.Route()
+	// the generated app name contains an underscore, which is replaced to make a valid host
+	host := strings.ReplaceAll(strings.ToLower(app.Name), "_", "-")
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+			Output:   g.Single(job.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output:   g.SinglePaged(app.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/routes", app.GUID),
+			Output:   g.Paged([]string{}),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations/e00705b9-7b42-4561-ae97-2520399d2133/domains/default",
+			Output:   g.Single(domain.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:      http.MethodGet,
+			Endpoint:    fmt.Sprintf("/v3/domains/%s/route_reservations", domain.GUID),
+			Output:      g.Single(`{ "matching_route": false }`),
+			Status:      http.StatusOK,
+			QueryString: fmt.Sprintf("host=%s&page=1&per_page=50", host),
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/routes",
+			Output:   g.Single(route.JSON),
+			Status:   http.StatusCreated,
+			PostForm: fmt.Sprintf(`{
+				"host": "%s",
+				"relationships": {
+					"domain": { "data": { "guid": "%s" } },
+					"space": { "data": { "guid": "%s" } }
+				}
+			}`, host, domain.GUID, space.GUID),
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/routes/%s/destinations", route.GUID),
+			Output:   g.Single(g.RouteDestinations().JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/packages",
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/upload", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/builds",
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/builds/%s", build.GUID),
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/droplets", pkg.GUID),
+			Output:   g.SinglePaged(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPatch,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", app.GUID),
+			Output:   g.Single(dropletAssoc.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/actions/start", app.GUID),
+			Output:   g.Single(app.JSON),
+			Status:   http.StatusOK,
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	manifest := &operation.AppManifest{
+		Name:         app.Name,
+		DefaultRoute: true,
+	}
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name)
+	_, err = pusher.Push(context.Background(), manifest, strings.NewReader("blah zip zip"))
+	require.NoError(t, err)
+}
+
+func TestAppPushNoRoute(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(8723)
+	org := g.Organization()
+	space := g.Space()
+	job := g.Job("COMPLETE")
+	app := g.Application()
+	pkg := g.Package("READY")
+	build := g.Build("STAGED")
+	droplet := g.Droplet()
+	dropletAssoc := g.DropletAssociation()
+	route := g.Route()
+	// the route's first destination is the pushed app, the second belongs to another app and is left alone
+	routeJSON := strings.Replace(route.JSON, "0a6636b5-7fc4-44d8-8752-0db3e40b35a5", app.GUID, 1)
+
+	routes := []testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/organizations",
+			Output:   g.SinglePaged(org.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/spaces",
+			Output:   g.SinglePaged(space.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", space.GUID),
+			Status:           http.StatusAccepted,
+			RedirectLocation: fmt.Sprintf("%s/v3/jobs/%s", serverURL, job.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/jobs/%s", job.GUID),
+			Output:   g.Single(job.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/apps",
+			Output:   g.SinglePaged(app.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/routes", app.GUID),
+			Output:   g.SinglePaged(routeJSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodDelete,
+			Endpoint: fmt.Sprintf("/v3/routes/%s/destinations/385bf117-17f5-4689-8c5c-08c6cc821fed", route.GUID),
+			Status:   http.StatusNoContent,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/packages",
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/upload", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s", pkg.GUID),
+			Output:   g.Single(pkg.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/builds",
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/builds/%s", build.GUID),
+			Output:   g.Single(build.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/packages/%s/droplets", pkg.GUID),
+			Output:   g.SinglePaged(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPatch,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", app.GUID),
+			Output:   g.Single(dropletAssoc.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/actions/start", app.GUID),
+			Output:   g.Single(app.JSON),
+			Status:   http.StatusOK,
+		},
+	}
+	testutil.SetupMultiple(routes, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	manifest := &operation.AppManifest{
+		Name:    app.Name,
+		NoRoute: true,
+	}
+	pusher := operation.NewAppPushOperation(cf, org.Name, space.Name)
+	_, err = pusher.Push(context.Background(), manifest, strings.NewReader("blah zip zip"))
+	require.NoError(t, err)
+}
@@ -0,0 +1,195 @@
+package operation
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/client"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"sort"
+	"strconv"
+)
+
+// RollbackOperation rolls an app back to one of its earlier revisions using a deployment
+type RollbackOperation struct {
+	client         *client.Client
+	pollingOptions *client.PollingOptions
+}
+
+// RollbackResult describes the deployment that rolled the app back and what changed between the revisions
+type RollbackResult struct {
+	From       *resource.Revision // the app's current revision before the rollback
+	To         *resource.Revision // the target revision
+	Deployment *resource.Deployment
+
+	DropletChanged bool
+
+	// only the environment variable names are reported since the values are often secrets
+	EnvVarsAdded   []string
+	EnvVarsRemoved []string
+	EnvVarsChanged []string
+
+	Processes []*RollbackProcessChange
+}
+
+// RollbackProcessChange is a process type whose command differs between the revisions, an empty command means
+// the process type doesn't exist in that revision
+type RollbackProcessChange struct {
+	Type        string
+	FromCommand string
+	ToCommand   string
+}
+
+// NewRollbackOperation creates a new RollbackOperation
+func NewRollbackOperation(client *client.Client) *RollbackOperation {
+	return &RollbackOperation{
+		client: client,
+	}
+}
+
+// WithPollingOptions sets the options used to wait for the rollback deployment to finish
+func (o *RollbackOperation) WithPollingOptions(opts *client.PollingOptions) *RollbackOperation {
+	o.pollingOptions = opts
+	return o
+}
+
+// Rollback deploys the app revision with the target version and waits for the deployment to finish
+//
+// The revision's droplet must still exist, Cloud Foundry only keeps a limited number of droplets per app. The
+// current revision is the most recent deployed revision.
+func (o *RollbackOperation) Rollback(ctx context.Context, appGUID string, targetVersion int) (*RollbackResult, error) {
+	from, err := o.currentRevision(ctx, appGUID)
+	if err != nil {
+		return nil, err
+	}
+	if from.Version == targetVersion {
+		return nil, fmt.Errorf("app %s is already at revision %d", appGUID, targetVersion)
+	}
+	to, err := o.targetRevision(ctx, appGUID, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := o.diff(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	newDeployment := resource.NewDeploymentCreate(appGUID)
+	newDeployment.Revision = &resource.DeploymentRevision{GUID: to.GUID}
+	deployment, err := o.client.Deployments.Create(ctx, newDeployment)
+	if err != nil {
+		return nil, fmt.Errorf("error creating deployment of app %s revision %d: %w", appGUID, targetVersion, err)
+	}
+	result.Deployment = deployment
+	err = o.client.Deployments.PollDeployed(ctx, deployment.GUID, o.pollingOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for deployment %s of app %s revision %d: %w", deployment.GUID, appGUID, targetVersion, err)
+	}
+	return result, nil
+}
+
+// currentRevision returns the app's deployed revision with the highest version
+func (o *RollbackOperation) currentRevision(ctx context.Context, appGUID string) (*resource.Revision, error) {
+	revisions, err := o.client.Revisions.ListForAppDeployedAll(ctx, appGUID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployed revisions of app %s: %w", appGUID, err)
+	}
+	var current *resource.Revision
+	for _, r := range revisions {
+		if current == nil || r.Version > current.Version {
+			current = r
+		}
+	}
+	if current == nil {
+		return nil, fmt.Errorf("app %s doesn't have a deployed revision", appGUID)
+	}
+	return current, nil
+}
+
+// targetRevision returns the revision with the version after checking it can still be deployed
+func (o *RollbackOperation) targetRevision(ctx context.Context, appGUID string, version int) (*resource.Revision, error) {
+	opts := client.NewRevisionListOptions()
+	opts.Versions.EqualTo(strconv.Itoa(version))
+	revision, err := o.client.Revisions.SingleForApp(ctx, appGUID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not find revision %d of app %s: %w", version, appGUID, err)
+	}
+
+	_, err = o.client.Droplets.Get(ctx, revision.Droplet.GUID)
+	if err != nil {
+		if resource.IsResourceNotFoundError(err) {
+			return nil, fmt.Errorf("revision %d of app %s can't be deployed because its droplet %s no longer exists",
+				version, appGUID, revision.Droplet.GUID)
+		}
+		return nil, fmt.Errorf("error getting droplet %s of revision %d: %w", revision.Droplet.GUID, version, err)
+	}
+	if !revision.Deployable {
+		return nil, fmt.Errorf("revision %d of app %s isn't deployable", version, appGUID)
+	}
+	return revision, nil
+}
+
+// diff compares the droplet, environment variables and process commands of the revisions
+func (o *RollbackOperation) diff(ctx context.Context, from, to *resource.Revision) (*RollbackResult, error) {
+	fromEnv, err := o.client.Revisions.GetEnvironmentVariables(ctx, from.GUID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting environment variables of revision %d: %w", from.Version, err)
+	}
+	toEnv, err := o.client.Revisions.GetEnvironmentVariables(ctx, to.GUID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting environment variables of revision %d: %w", to.Version, err)
+	}
+
+	result := &RollbackResult{
+		From:           from,
+		To:             to,
+		DropletChanged: from.Droplet.GUID != to.Droplet.GUID,
+	}
+	for name, value := range toEnv {
+		fromValue, ok := fromEnv[name]
+		switch {
+		case !ok:
+			result.EnvVarsAdded = append(result.EnvVarsAdded, name)
+		case !equalEnvValue(fromValue, value):
+			result.EnvVarsChanged = append(result.EnvVarsChanged, name)
+		}
+	}
+	for name := range fromEnv {
+		if _, ok := toEnv[name]; !ok {
+			result.EnvVarsRemoved = append(result.EnvVarsRemoved, name)
+		}
+	}
+	sort.Strings(result.EnvVarsAdded)
+	sort.Strings(result.EnvVarsRemoved)
+	sort.Strings(result.EnvVarsChanged)
+
+	processTypes := make([]string, 0, len(from.Processes)+len(to.Processes))
+	for processType := range from.Processes {
+		processTypes = append(processTypes, processType)
+	}
+	for processType := range to.Processes {
+		if _, ok := from.Processes[processType]; !ok {
+			processTypes = append(processTypes, processType)
+		}
+	}
+	sort.Strings(processTypes)
+	for _, processType := range processTypes {
+		fromCommand := from.Processes[processType].Command
+		toCommand := to.Processes[processType].Command
+		if fromCommand != toCommand {
+			result.Processes = append(result.Processes, &RollbackProcessChange{
+				Type:        processType,
+				FromCommand: fromCommand,
+				ToCommand:   toCommand,
+			})
+		}
+	}
+	return result, nil
+}
+
+func equalEnvValue(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
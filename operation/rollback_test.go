@@ -0,0 +1,187 @@
+package operation_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/client"
+	"github.com/cloudfoundry-community/go-cfclient/v3/config"
+	"github.com/cloudfoundry-community/go-cfclient/v3/operation"
+	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRollback(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(4102)
+	app := g.Application()
+	target := g.Revision()
+	current := g.Revision()
+	currentJSON := strings.NewReplacer(
+		`"version": 1`, `"version": 3`,
+		"585bc3c1-3743-497d-88b0-403ad6b56d16", "a7cbb3c4-cdb7-4df2-9e5a-b8a1e4bba5d5",
+		"bundle exec rackup", "bundle exec puma",
+		`"web": {`, `"scheduler": { "command": "bin/scheduler" }, "web": {`,
+	).Replace(current.JSON)
+	targetJSON := strings.Replace(target.JSON, `"web": {`, `"clock": { "command": "bin/clock" }, "web": {`, 1)
+	droplet := g.Droplet()
+	deployment := g.Deployment()
+	deployed := g.DeploymentWithStatus("FINALIZED", "DEPLOYED")
+
+	testutil.SetupMultiple([]testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/revisions/deployed", app.GUID),
+			Output:   g.SinglePaged(currentJSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:      http.MethodGet,
+			Endpoint:    fmt.Sprintf("/v3/apps/%s/revisions", app.GUID),
+			Output:      g.SinglePaged(targetJSON),
+			Status:      http.StatusOK,
+			QueryString: "page=1&per_page=50&versions=1",
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/droplets/585bc3c1-3743-497d-88b0-403ad6b56d16",
+			Output:   g.Single(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/revisions/%s/environment_variables", current.GUID),
+			Output:   g.Single(`{ "var": { "RAILS_ENV": "production", "FEATURE_X": "on", "DB_URL": "new" } }`),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/revisions/%s/environment_variables", target.GUID),
+			Output:   g.Single(`{ "var": { "RAILS_ENV": "production", "LEGACY": "1", "DB_URL": "old" } }`),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodPost,
+			Endpoint: "/v3/deployments",
+			Output:   g.Single(deployment.JSON),
+			Status:   http.StatusCreated,
+			PostForm: fmt.Sprintf(`{
+				"relationships": { "app": { "data": { "guid": "%s" } } },
+				"revision": { "guid": "%s" }
+			}`, app.GUID, target.GUID),
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/deployments/%s", deployment.GUID),
+			Output:   []string{deployment.JSON, deployed.JSON},
+			Status:   http.StatusOK,
+		},
+	}, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	op := operation.NewRollbackOperation(cf).WithPollingOptions(&client.PollingOptions{
+		Timeout:       time.Second,
+		CheckInterval: 10 * time.Millisecond,
+		FailedState:   "FAILED",
+	})
+	result, err := op.Rollback(context.Background(), app.GUID, 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, result.From.Version)
+	require.Equal(t, target.GUID, result.To.GUID)
+	require.Equal(t, deployment.GUID, result.Deployment.GUID)
+	require.True(t, result.DropletChanged)
+	require.Equal(t, []string{"LEGACY"}, result.EnvVarsAdded)
+	require.Equal(t, []string{"FEATURE_X"}, result.EnvVarsRemoved)
+	require.Equal(t, []string{"DB_URL"}, result.EnvVarsChanged)
+	require.Equal(t, []*operation.RollbackProcessChange{
+		{Type: "clock", FromCommand: "", ToCommand: "bin/clock"},
+		{Type: "scheduler", FromCommand: "bin/scheduler", ToCommand: ""},
+		{Type: "web", FromCommand: "bundle exec puma", ToCommand: "bundle exec rackup"},
+	}, result.Processes)
+}
+
+func TestRollbackDropletDeleted(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(4102)
+	app := g.Application()
+	target := g.Revision()
+	current := g.Revision()
+
+	testutil.SetupMultiple([]testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/revisions/deployed", app.GUID),
+			Output:   g.SinglePaged(strings.Replace(current.JSON, `"version": 1`, `"version": 2`, 1)),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/revisions", app.GUID),
+			Output:   g.SinglePaged(target.JSON),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/droplets/585bc3c1-3743-497d-88b0-403ad6b56d16",
+			Output:   []string{`{"errors":[{"code":10010,"title":"CF-ResourceNotFound","detail":"Droplet not found"}]}`},
+			Status:   http.StatusNotFound,
+		},
+	}, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	_, err = operation.NewRollbackOperation(cf).Rollback(context.Background(), app.GUID, 1)
+	require.ErrorContains(t, err, "droplet 585bc3c1-3743-497d-88b0-403ad6b56d16 no longer exists")
+}
+
+func TestRollbackRevisionNotDeployable(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(4102)
+	app := g.Application()
+	target := g.Revision()
+	current := g.Revision()
+	droplet := g.Droplet()
+
+	testutil.SetupMultiple([]testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/revisions/deployed", app.GUID),
+			Output:   g.SinglePaged(strings.Replace(current.JSON, `"version": 1`, `"version": 2`, 1)),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: fmt.Sprintf("/v3/apps/%s/revisions", app.GUID),
+			Output:   g.SinglePaged(strings.Replace(target.JSON, `"deployable": true`, `"deployable": false`, 1)),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/droplets/585bc3c1-3743-497d-88b0-403ad6b56d16",
+			Output:   g.Single(droplet.JSON),
+			Status:   http.StatusOK,
+		},
+	}, t)
+
+	c, _ := config.NewToken(serverURL, "foo")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	_, err = operation.NewRollbackOperation(cf).Rollback(context.Background(), app.GUID, 1)
+	require.ErrorContains(t, err, fmt.Sprintf("revision 1 of app %s isn't deployable", app.GUID))
+	require.NotContains(t, err.Error(), "no longer exists")
+}
@@ -0,0 +1,289 @@
+package operation
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/client"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"reflect"
+	"sort"
+)
+
+// ServiceBrokerSyncOperation registers or updates a service broker and reports how its catalog changed
+type ServiceBrokerSyncOperation struct {
+	client         *client.Client
+	pollingOptions *client.PollingOptions
+}
+
+// CatalogSyncReport describes how a service broker's catalog changed after synchronization
+type CatalogSyncReport struct {
+	Broker  *resource.ServiceBroker
+	Created bool // true if the broker was registered, false if it already existed and was updated
+
+	AddedOfferings   []*resource.ServiceOffering
+	RemovedOfferings []*resource.ServiceOffering
+
+	AddedPlans   []*CatalogPlan
+	RemovedPlans []*CatalogPlan
+	ChangedPlans []*CatalogPlanChange
+}
+
+// CatalogPlan is a service plan along with the name of its service offering
+type CatalogPlan struct {
+	OfferingName string
+	Plan         *resource.ServicePlan
+}
+
+// CatalogPlanChange is a service plan that exists both before and after synchronization but has changed
+type CatalogPlanChange struct {
+	OfferingName string
+	Before       *resource.ServicePlan
+	After        *resource.ServicePlan
+
+	// Fields lists the names of the changed plan fields, e.g. description or maintenance_info
+	Fields []string
+}
+
+// NewServiceBrokerSyncOperation creates a new ServiceBrokerSyncOperation
+func NewServiceBrokerSyncOperation(client *client.Client) *ServiceBrokerSyncOperation {
+	return &ServiceBrokerSyncOperation{
+		client: client,
+	}
+}
+
+// WithPollingOptions sets the options used to wait for the broker's catalog synchronization job, the default
+// timeout may be too short for brokers with large catalogs
+func (o *ServiceBrokerSyncOperation) WithPollingOptions(opts *client.PollingOptions) *ServiceBrokerSyncOperation {
+	o.pollingOptions = opts
+	return o
+}
+
+// Sync registers the service broker, or updates its URL and credentials if a broker with the same name already
+// exists, waits for the catalog synchronization job to complete and then reports the catalog changes
+func (o *ServiceBrokerSyncOperation) Sync(ctx context.Context, r *resource.ServiceBrokerCreate) (*CatalogSyncReport, error) {
+	broker, err := o.findBroker(ctx, r.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	created := broker == nil
+	var before *catalog
+	if created {
+		before = &catalog{}
+		jobGUID, err := o.client.ServiceBrokers.Create(ctx, r)
+		if err != nil {
+			return nil, fmt.Errorf("error registering service broker %s: %w", r.Name, err)
+		}
+		err = o.waitForSync(ctx, r.Name, jobGUID)
+		if err != nil {
+			return nil, err
+		}
+		broker, err = o.findBroker(ctx, r.Name)
+		if err != nil {
+			return nil, err
+		}
+		if broker == nil {
+			return nil, fmt.Errorf("could not find service broker %s after registering it", r.Name)
+		}
+	} else {
+		before, err = o.getCatalog(ctx, broker)
+		if err != nil {
+			return nil, err
+		}
+		update := resource.NewServiceBrokerUpdate().WithURL(r.URL)
+		update.Authentication = &r.Authentication
+		jobGUID, _, err := o.client.ServiceBrokers.Update(ctx, broker.GUID, update)
+		if err != nil {
+			return nil, fmt.Errorf("error updating service broker %s: %w", r.Name, err)
+		}
+		err = o.waitForSync(ctx, r.Name, jobGUID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	after, err := o.getCatalog(ctx, broker)
+	if err != nil {
+		return nil, err
+	}
+	report := diffCatalogs(before, after)
+	report.Broker = broker
+	report.Created = created
+	return report, nil
+}
+
+// HasChanges returns true if any service offerings or plans were added, removed or changed
+func (r *CatalogSyncReport) HasChanges() bool {
+	return len(r.AddedOfferings) > 0 || len(r.RemovedOfferings) > 0 ||
+		len(r.AddedPlans) > 0 || len(r.RemovedPlans) > 0 || len(r.ChangedPlans) > 0
+}
+
+// UpgradeablePlans returns the changed plans whose maintenance_info version changed, existing service instances
+// of these plans will have an upgrade available
+func (r *CatalogSyncReport) UpgradeablePlans() []*CatalogPlanChange {
+	var upgradeable []*CatalogPlanChange
+	for _, c := range r.ChangedPlans {
+		if c.MaintenanceInfoChanged() {
+			upgradeable = append(upgradeable, c)
+		}
+	}
+	return upgradeable
+}
+
+// MaintenanceInfoChanged returns true if the plan maintenance_info version changed, which makes an upgrade
+// available for existing service instances of the plan
+func (c *CatalogPlanChange) MaintenanceInfoChanged() bool {
+	return c.Before.MaintenanceInfo.Version != c.After.MaintenanceInfo.Version
+}
+
+// catalog is a snapshot of a service broker's service offerings and plans
+type catalog struct {
+	offerings []*resource.ServiceOffering
+	plans     []*resource.ServicePlan
+}
+
+func (o *ServiceBrokerSyncOperation) findBroker(ctx context.Context, name string) (*resource.ServiceBroker, error) {
+	opts := client.NewServiceBrokerListOptions()
+	opts.Names.EqualTo(name)
+	brokers, err := o.client.ServiceBrokers.ListAll(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not find service broker %s: %w", name, err)
+	}
+	if len(brokers) == 0 {
+		return nil, nil
+	}
+	return brokers[0], nil
+}
+
+func (o *ServiceBrokerSyncOperation) waitForSync(ctx context.Context, name, jobGUID string) error {
+	if jobGUID == "" {
+		return nil
+	}
+	err := o.client.Jobs.PollComplete(ctx, jobGUID, o.pollingOptions)
+	if err != nil {
+		return fmt.Errorf("error waiting for service broker %s catalog to synchronize: %w", name, err)
+	}
+	return nil
+}
+
+func (o *ServiceBrokerSyncOperation) getCatalog(ctx context.Context, broker *resource.ServiceBroker) (*catalog, error) {
+	offeringOpts := client.NewServiceOfferingListOptions()
+	offeringOpts.ServiceBrokerGUIDs.EqualTo(broker.GUID)
+	offerings, err := o.client.ServiceOfferings.ListAll(ctx, offeringOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing service broker %s offerings: %w", broker.Name, err)
+	}
+
+	planOpts := client.NewServicePlanListOptions()
+	planOpts.ServiceBrokerGUIDs.EqualTo(broker.GUID)
+	plans, err := o.client.ServicePlans.ListAll(ctx, planOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing service broker %s plans: %w", broker.Name, err)
+	}
+	return &catalog{
+		offerings: offerings,
+		plans:     plans,
+	}, nil
+}
+
+// diffCatalogs compares the offerings and plans of two catalog snapshots, matching them by their broker catalog ID
+func diffCatalogs(before, after *catalog) *CatalogSyncReport {
+	report := &CatalogSyncReport{}
+
+	offeringNames := make(map[string]string)
+	for _, c := range []*catalog{before, after} {
+		for _, offering := range c.offerings {
+			offeringNames[offering.GUID] = offering.Name
+		}
+	}
+	offeringName := func(p *resource.ServicePlan) string {
+		if p.Relationships.ServiceOffering.Data == nil {
+			return ""
+		}
+		return offeringNames[p.Relationships.ServiceOffering.Data.GUID]
+	}
+
+	beforeOfferings := make(map[string]*resource.ServiceOffering)
+	for _, offering := range before.offerings {
+		beforeOfferings[offering.BrokerCatalog.ID] = offering
+	}
+	for _, offering := range after.offerings {
+		if _, ok := beforeOfferings[offering.BrokerCatalog.ID]; ok {
+			delete(beforeOfferings, offering.BrokerCatalog.ID)
+			continue
+		}
+		report.AddedOfferings = append(report.AddedOfferings, offering)
+	}
+	for _, offering := range beforeOfferings {
+		report.RemovedOfferings = append(report.RemovedOfferings, offering)
+	}
+
+	beforePlans := make(map[string]*resource.ServicePlan)
+	for _, plan := range before.plans {
+		beforePlans[plan.BrokerCatalog.ID] = plan
+	}
+	for _, plan := range after.plans {
+		beforePlan, ok := beforePlans[plan.BrokerCatalog.ID]
+		if !ok {
+			report.AddedPlans = append(report.AddedPlans, &CatalogPlan{OfferingName: offeringName(plan), Plan: plan})
+			continue
+		}
+		delete(beforePlans, plan.BrokerCatalog.ID)
+		if fields := changedPlanFields(beforePlan, plan); len(fields) > 0 {
+			report.ChangedPlans = append(report.ChangedPlans, &CatalogPlanChange{
+				OfferingName: offeringName(plan),
+				Before:       beforePlan,
+				After:        plan,
+				Fields:       fields,
+			})
+		}
+	}
+	for _, plan := range beforePlans {
+		report.RemovedPlans = append(report.RemovedPlans, &CatalogPlan{OfferingName: offeringName(plan), Plan: plan})
+	}
+
+	sort.Slice(report.AddedOfferings, func(i, j int) bool {
+		return report.AddedOfferings[i].Name < report.AddedOfferings[j].Name
+	})
+	sort.Slice(report.RemovedOfferings, func(i, j int) bool {
+		return report.RemovedOfferings[i].Name < report.RemovedOfferings[j].Name
+	})
+	sortCatalogPlans(report.AddedPlans)
+	sortCatalogPlans(report.RemovedPlans)
+	sort.Slice(report.ChangedPlans, func(i, j int) bool {
+		a, b := report.ChangedPlans[i], report.ChangedPlans[j]
+		if a.OfferingName != b.OfferingName {
+			return a.OfferingName < b.OfferingName
+		}
+		return a.After.Name < b.After.Name
+	})
+	return report
+}
+
+// changedPlanFields returns the names of the broker provided plan fields that differ
+func changedPlanFields(before, after *resource.ServicePlan) []string {
+	var fields []string
+	add := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	add("name", before.Name != after.Name)
+	add("description", before.Description != after.Description)
+	add("available", before.Available != after.Available)
+	add("free", before.Free != after.Free)
+	add("costs", !reflect.DeepEqual(before.Costs, after.Costs))
+	add("maintenance_info", before.MaintenanceInfo != after.MaintenanceInfo)
+	add("broker_catalog", !reflect.DeepEqual(before.BrokerCatalog, after.BrokerCatalog))
+	add("schemas", !reflect.DeepEqual(before.Schemas, after.Schemas))
+	return fields
+}
+
+func sortCatalogPlans(plans []*CatalogPlan) {
+	sort.Slice(plans, func(i, j int) bool {
+		if plans[i].OfferingName != plans[j].OfferingName {
+			return plans[i].OfferingName < plans[j].OfferingName
+		}
+		return plans[i].Plan.Name < plans[j].Plan.Name
+	})
+}
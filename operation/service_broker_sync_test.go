@@ -0,0 +1,212 @@
+package operation_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cloudfoundry-community/go-cfclient/v3/client"
+	"github.com/cloudfoundry-community/go-cfclient/v3/config"
+	"github.com/cloudfoundry-community/go-cfclient/v3/operation"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServiceBrokerSyncUpdate(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(2)
+	broker := g.ServiceBroker()
+	job := g.Job("COMPLETE")
+
+	mysql := newCatalogOffering("mysql-guid", "mysql", "mysql-id")
+	redis := newCatalogOffering("redis-guid", "redis", "redis-id")
+	small := newCatalogPlan("small-guid", "small", "small-id", mysql, "1.0.0")
+	smallUpgraded := newCatalogPlan("small-guid", "small", "small-id", mysql, "1.1.0")
+	large := newCatalogPlan("large-guid", "large", "large-id", mysql, "1.0.0")
+	largeRepriced := newCatalogPlan("large-guid", "large", "large-id", mysql, "1.0.0")
+	largeRepriced.Description = "Now even larger"
+	cache := newCatalogPlan("cache-guid", "cache", "cache-id", redis, "1.0.0")
+	removed := newCatalogPlan("xl-guid", "xl", "xl-id", mysql, "1.0.0")
+
+	testutil.SetupMultiple([]testutil.MockRoute{
+		{
+			Method:      http.MethodGet,
+			Endpoint:    "/v3/service_brokers",
+			Output:      g.SinglePaged(broker.JSON),
+			Status:      http.StatusOK,
+			QueryString: "names=" + broker.Name + "&page=1&per_page=50",
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/service_offerings",
+			Output: []string{
+				g.Paged([]string{toJSON(t, mysql)})[0],
+				g.Paged([]string{toJSON(t, mysql), toJSON(t, redis)})[0],
+			},
+			Status: http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/service_plans",
+			Output: []string{
+				g.Paged([]string{toJSON(t, small), toJSON(t, large), toJSON(t, removed)})[0],
+				g.Paged([]string{toJSON(t, smallUpgraded), toJSON(t, largeRepriced), toJSON(t, cache)})[0],
+			},
+			Status: http.StatusOK,
+		},
+		{
+			Method:           http.MethodPatch,
+			Endpoint:         "/v3/service_brokers/" + broker.GUID,
+			Status:           http.StatusAccepted,
+			RedirectLocation: "https://api.example.org/v3/jobs/" + job.GUID,
+			PostForm: `{
+				"url": "https://broker.example.org",
+				"authentication": {
+					"type": "basic",
+					"credentials": { "username": "admin", "password": "secret" }
+				}
+			}`,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/jobs/" + job.GUID,
+			Output:   []string{job.JSON},
+			Status:   http.StatusOK,
+		},
+	}, t)
+
+	c, _ := config.NewToken(serverURL, "foobar")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	opts := client.NewPollingOptions()
+	opts.CheckInterval = time.Millisecond
+	op := operation.NewServiceBrokerSyncOperation(cf).WithPollingOptions(opts)
+	r := resource.NewServiceBrokerCreate(broker.Name, "https://broker.example.org", "admin", "secret")
+	report, err := op.Sync(context.Background(), r)
+	require.NoError(t, err)
+
+	require.False(t, report.Created)
+	require.Equal(t, broker.GUID, report.Broker.GUID)
+	require.True(t, report.HasChanges())
+
+	require.Len(t, report.AddedOfferings, 1)
+	require.Equal(t, "redis", report.AddedOfferings[0].Name)
+	require.Empty(t, report.RemovedOfferings)
+
+	require.Len(t, report.AddedPlans, 1)
+	require.Equal(t, "redis", report.AddedPlans[0].OfferingName)
+	require.Equal(t, "cache", report.AddedPlans[0].Plan.Name)
+	require.Len(t, report.RemovedPlans, 1)
+	require.Equal(t, "xl", report.RemovedPlans[0].Plan.Name)
+
+	require.Len(t, report.ChangedPlans, 2)
+	require.Equal(t, "large", report.ChangedPlans[0].After.Name)
+	require.Equal(t, []string{"description"}, report.ChangedPlans[0].Fields)
+	require.False(t, report.ChangedPlans[0].MaintenanceInfoChanged())
+	require.Equal(t, "small", report.ChangedPlans[1].After.Name)
+	require.Equal(t, []string{"maintenance_info"}, report.ChangedPlans[1].Fields)
+
+	upgradeable := report.UpgradeablePlans()
+	require.Len(t, upgradeable, 1)
+	require.Equal(t, "mysql", upgradeable[0].OfferingName)
+	require.Equal(t, "1.0.0", upgradeable[0].Before.MaintenanceInfo.Version)
+	require.Equal(t, "1.1.0", upgradeable[0].After.MaintenanceInfo.Version)
+}
+
+func TestServiceBrokerSyncCreate(t *testing.T) {
+	serverURL := testutil.SetupFakeAPIServer()
+	defer testutil.Teardown()
+
+	g := testutil.NewObjectJSONGenerator(3)
+	broker := g.ServiceBroker()
+	job := g.Job("COMPLETE")
+	mysql := newCatalogOffering("mysql-guid", "mysql", "mysql-id")
+	small := newCatalogPlan("small-guid", "small", "small-id", mysql, "1.0.0")
+
+	testutil.SetupMultiple([]testutil.MockRoute{
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/service_brokers",
+			Output:   []string{g.Paged([]string{})[0], g.SinglePaged(broker.JSON)[0]},
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/service_offerings",
+			Output:   g.SinglePaged(toJSON(t, mysql)),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/service_plans",
+			Output:   g.SinglePaged(toJSON(t, small)),
+			Status:   http.StatusOK,
+		},
+		{
+			Method:           http.MethodPost,
+			Endpoint:         "/v3/service_brokers",
+			Status:           http.StatusAccepted,
+			RedirectLocation: "https://api.example.org/v3/jobs/" + job.GUID,
+		},
+		{
+			Method:   http.MethodGet,
+			Endpoint: "/v3/jobs/" + job.GUID,
+			Output:   []string{job.JSON},
+			Status:   http.StatusOK,
+		},
+	}, t)
+
+	c, _ := config.NewToken(serverURL, "foobar")
+	cf, err := client.New(c)
+	require.NoError(t, err)
+
+	r := resource.NewServiceBrokerCreate(broker.Name, "https://broker.example.org", "admin", "secret")
+	opts := client.NewPollingOptions()
+	opts.CheckInterval = time.Millisecond
+	report, err := operation.NewServiceBrokerSyncOperation(cf).WithPollingOptions(opts).Sync(context.Background(), r)
+	require.NoError(t, err)
+	require.True(t, report.Created)
+	require.Len(t, report.AddedOfferings, 1)
+	require.Len(t, report.AddedPlans, 1)
+	require.Empty(t, report.ChangedPlans)
+	require.Empty(t, report.UpgradeablePlans())
+}
+
+func newCatalogOffering(guid, name, catalogID string) *resource.ServiceOffering {
+	return &resource.ServiceOffering{
+		GUID: guid,
+		Name: name,
+		BrokerCatalog: resource.ServiceOfferingBrokerCatalog{
+			ID: catalogID,
+		},
+	}
+}
+
+func newCatalogPlan(guid, name, catalogID string, offering *resource.ServiceOffering, version string) *resource.ServicePlan {
+	return &resource.ServicePlan{
+		GUID: guid,
+		Name: name,
+		MaintenanceInfo: resource.ServicePlanMaintenanceInfo{
+			Version: version,
+		},
+		BrokerCatalog: resource.ServicePlanBrokerCatalog{
+			ID: catalogID,
+		},
+		Relationships: resource.ServicePlanRelationship{
+			ServiceOffering: resource.ToOneRelationship{
+				Data: &resource.Relationship{GUID: offering.GUID},
+			},
+		},
+	}
+}
+
+func toJSON(t *testing.T, v any) string {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
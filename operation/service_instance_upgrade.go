@@ -0,0 +1,293 @@
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/client"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"sort"
+	"sync"
+)
+
+// DefaultUpgradeConcurrency is the default number of service instances upgraded at the same time
+const DefaultUpgradeConcurrency = 5
+
+// ServiceInstanceUpgradeStatus is the upgrade status of a single service instance
+type ServiceInstanceUpgradeStatus string
+
+const (
+	ServiceInstanceUpgradePending  ServiceInstanceUpgradeStatus = "pending"
+	ServiceInstanceUpgradeUpgraded ServiceInstanceUpgradeStatus = "upgraded"
+	ServiceInstanceUpgradeFailed   ServiceInstanceUpgradeStatus = "failed"
+
+	// ServiceInstanceUpgradeSkipped is a service instance from a resumed report that wasn't upgraded and no longer
+	// matches the filter or no longer has an upgrade available, e.g. because it was upgraded by hand
+	ServiceInstanceUpgradeSkipped ServiceInstanceUpgradeStatus = "skipped"
+)
+
+// ServiceInstanceUpgradeOperation upgrades managed service instances that have an upgrade available to the
+// maintenance_info version of their service plan
+type ServiceInstanceUpgradeOperation struct {
+	client         *client.Client
+	concurrency    int
+	dryRun         bool
+	pollingOptions *client.PollingOptions
+	onProgress     func(result *ServiceInstanceUpgradeResult)
+}
+
+// ServiceInstanceUpgradeFilter selects the service instances to upgrade, empty fields match everything
+type ServiceInstanceUpgradeFilter struct {
+	ServiceOfferingNames []string
+	ServicePlanNames     []string
+	OrganizationGUIDs    []string
+	LabelSelector        string // e.g. environment=production,tier!=backend
+}
+
+// ServiceInstanceUpgradeReport is the progress of an upgrade run
+//
+// The report can be serialized as JSON and passed to a later Upgrade call to resume the run, in which case
+// service instances that were already upgraded are skipped and failed instances are retried. Failed or pending
+// instances that are no longer upgradeable when the run is resumed are marked as skipped.
+type ServiceInstanceUpgradeReport struct {
+	DryRun    bool                                     `json:"dry_run"`
+	Instances map[string]*ServiceInstanceUpgradeResult `json:"instances"` // keyed by service instance GUID
+
+	mutex sync.Mutex
+}
+
+// ServiceInstanceUpgradeResult is the upgrade progress of a single service instance
+type ServiceInstanceUpgradeResult struct {
+	GUID        string                       `json:"guid"`
+	Name        string                       `json:"name"`
+	SpaceGUID   string                       `json:"space_guid"`
+	PlanName    string                       `json:"plan_name"`
+	FromVersion string                       `json:"from_version"`
+	ToVersion   string                       `json:"to_version"`
+	Status      ServiceInstanceUpgradeStatus `json:"status"`
+	Error       string                       `json:"error,omitempty"`
+}
+
+// NewServiceInstanceUpgradeOperation creates a new ServiceInstanceUpgradeOperation
+func NewServiceInstanceUpgradeOperation(client *client.Client) *ServiceInstanceUpgradeOperation {
+	return &ServiceInstanceUpgradeOperation{
+		client:      client,
+		concurrency: DefaultUpgradeConcurrency,
+	}
+}
+
+// WithConcurrency sets the maximum number of service instances upgraded at the same time
+func (o *ServiceInstanceUpgradeOperation) WithConcurrency(concurrency int) *ServiceInstanceUpgradeOperation {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	o.concurrency = concurrency
+	return o
+}
+
+// WithDryRun reports the service instances that would be upgraded without upgrading them
+func (o *ServiceInstanceUpgradeOperation) WithDryRun(dryRun bool) *ServiceInstanceUpgradeOperation {
+	o.dryRun = dryRun
+	return o
+}
+
+// WithPollingOptions sets the options used to wait for each service instance upgrade, brokers may take a long
+// time to upgrade an instance so the timeout should be set accordingly
+func (o *ServiceInstanceUpgradeOperation) WithPollingOptions(opts *client.PollingOptions) *ServiceInstanceUpgradeOperation {
+	o.pollingOptions = opts
+	return o
+}
+
+// WithProgress sets a callback that's invoked each time a service instance upgrade finishes, for example to
+// persist the report so the run can be resumed. The callback may be invoked concurrently.
+func (o *ServiceInstanceUpgradeOperation) WithProgress(onProgress func(result *ServiceInstanceUpgradeResult)) *ServiceInstanceUpgradeOperation {
+	o.onProgress = onProgress
+	return o
+}
+
+// Upgrade finds the service instances matching the filter that have an upgrade available and upgrades them
+//
+// To resume an earlier run pass its report, otherwise pass nil. Individual service instance upgrade failures are
+// recorded in the report rather than returned as an error. If the context is cancelled no new upgrades are started,
+// any remaining service instances are left pending and the report is returned along with the context error.
+func (o *ServiceInstanceUpgradeOperation) Upgrade(ctx context.Context, filter ServiceInstanceUpgradeFilter, resume *ServiceInstanceUpgradeReport) (*ServiceInstanceUpgradeReport, error) {
+	report := resume
+	if report == nil || report.DryRun != o.dryRun {
+		report = &ServiceInstanceUpgradeReport{}
+	}
+	report.DryRun = o.dryRun
+	if report.Instances == nil {
+		report.Instances = make(map[string]*ServiceInstanceUpgradeResult)
+	}
+
+	instances, plans, err := o.findUpgradeable(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	upgradeable := make(map[string]bool, len(instances))
+	for _, si := range instances {
+		upgradeable[si.GUID] = true
+	}
+	for guid, r := range report.Instances {
+		if !upgradeable[guid] && r.Status != ServiceInstanceUpgradeUpgraded {
+			r.Status = ServiceInstanceUpgradeSkipped
+			r.Error = ""
+		}
+	}
+
+	var todo []*ServiceInstanceUpgradeResult
+	for _, si := range instances {
+		if r, ok := report.Instances[si.GUID]; ok && r.Status == ServiceInstanceUpgradeUpgraded {
+			continue
+		}
+		plan := plans[si.Relationships.ServicePlan.Data.GUID]
+		r := &ServiceInstanceUpgradeResult{
+			GUID:      si.GUID,
+			Name:      si.Name,
+			PlanName:  plan.Name,
+			ToVersion: plan.MaintenanceInfo.Version,
+			Status:    ServiceInstanceUpgradePending,
+		}
+		if si.Relationships.Space != nil && si.Relationships.Space.Data != nil {
+			r.SpaceGUID = si.Relationships.Space.Data.GUID
+		}
+		if si.MaintenanceInfo != nil {
+			r.FromVersion = si.MaintenanceInfo.Version
+		}
+		report.Instances[si.GUID] = r
+		todo = append(todo, r)
+	}
+	if o.dryRun {
+		return report, nil
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, o.concurrency)
+launch:
+	for _, r := range todo {
+		select {
+		case <-ctx.Done():
+			break launch
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(r *ServiceInstanceUpgradeResult) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			o.upgrade(ctx, report, r)
+		}(r)
+	}
+	wg.Wait()
+	return report, ctx.Err()
+}
+
+// MarshalJSON serializes the report, it's safe to call while an upgrade is in progress
+func (r *ServiceInstanceUpgradeReport) MarshalJSON() ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return json.Marshal(struct {
+		DryRun    bool                                     `json:"dry_run"`
+		Instances map[string]*ServiceInstanceUpgradeResult `json:"instances"`
+	}{
+		DryRun:    r.DryRun,
+		Instances: r.Instances,
+	})
+}
+
+// Results returns the results with the specified status ordered by service instance name
+func (r *ServiceInstanceUpgradeReport) Results(status ServiceInstanceUpgradeStatus) []*ServiceInstanceUpgradeResult {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var results []*ServiceInstanceUpgradeResult
+	for _, result := range r.Instances {
+		if result.Status == status {
+			results = append(results, result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+func (o *ServiceInstanceUpgradeOperation) upgrade(ctx context.Context, report *ServiceInstanceUpgradeReport, r *ServiceInstanceUpgradeResult) {
+	update := &resource.ServiceInstanceManagedUpdate{
+		MaintenanceInfo: &resource.ServiceInstanceMaintenanceInfo{
+			Version: r.ToVersion,
+		},
+	}
+	_, err := o.client.ServiceInstances.UpdateManagedAndWait(ctx, r.GUID, update, o.pollingOptions)
+
+	report.mutex.Lock()
+	if err != nil {
+		r.Status = ServiceInstanceUpgradeFailed
+		r.Error = err.Error()
+	} else {
+		r.Status = ServiceInstanceUpgradeUpgraded
+		r.Error = ""
+	}
+	report.mutex.Unlock()
+
+	if o.onProgress != nil {
+		o.onProgress(r)
+	}
+}
+
+// findUpgradeable returns the service instances matching the filter that have an upgrade available along with
+// their service plans keyed by GUID
+func (o *ServiceInstanceUpgradeOperation) findUpgradeable(ctx context.Context, filter ServiceInstanceUpgradeFilter) ([]*resource.ServiceInstance, map[string]*resource.ServicePlan, error) {
+	planOpts := client.NewServicePlanListOptions()
+	if len(filter.ServiceOfferingNames) > 0 {
+		planOpts.ServiceOfferingNames.EqualTo(filter.ServiceOfferingNames...)
+	}
+	if len(filter.ServicePlanNames) > 0 {
+		planOpts.Names.EqualTo(filter.ServicePlanNames...)
+	}
+	plans, err := o.client.ServicePlans.ListAll(ctx, planOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing service plans: %w", err)
+	}
+	if len(plans) == 0 {
+		return nil, nil, nil
+	}
+	plansByGUID := make(map[string]*resource.ServicePlan, len(plans))
+	planGUIDs := make([]string, 0, len(plans))
+	for _, plan := range plans {
+		plansByGUID[plan.GUID] = plan
+		planGUIDs = append(planGUIDs, plan.GUID)
+	}
+
+	opts := client.NewServiceInstanceListOptions()
+	opts.Type = "managed"
+	if len(filter.ServiceOfferingNames) > 0 || len(filter.ServicePlanNames) > 0 {
+		opts.ServicePlanGUIDs.EqualTo(planGUIDs...)
+	}
+	if len(filter.OrganizationGUIDs) > 0 {
+		opts.OrganizationGUIDs.EqualTo(filter.OrganizationGUIDs...)
+	}
+	if filter.LabelSelector != "" {
+		opts.LabelSelector.EqualTo(filter.LabelSelector)
+	}
+	instances, err := o.client.ServiceInstances.ListAll(ctx, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing service instances: %w", err)
+	}
+
+	var upgradeable []*resource.ServiceInstance
+	for _, si := range instances {
+		if si.UpgradeAvailable == nil || !*si.UpgradeAvailable {
+			continue
+		}
+		if si.Relationships.ServicePlan == nil || si.Relationships.ServicePlan.Data == nil {
+			continue
+		}
+		if _, ok := plansByGUID[si.Relationships.ServicePlan.Data.GUID]; !ok {
+			continue
+		}
+		upgradeable = append(upgradeable, si)
+	}
+	return upgradeable, plansByGUID, nil
+}
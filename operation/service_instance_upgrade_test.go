@@ -0,0 +1,211 @@
+package operation_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cloudfoundry-community/go-cfclient/v3/client"
+	"github.com/cloudfoundry-community/go-cfclient/v3/config"
+	"github.com/cloudfoundry-community/go-cfclient/v3/operation"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"github.com/cloudfoundry-community/go-cfclient/v3/testutil"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServiceInstanceUpgrade(t *testing.T) {
+	g := testutil.NewObjectJSONGenerator(4)
+	job := g.Job("COMPLETE")
+	mysql := newCatalogOffering("mysql-guid", "mysql", "mysql-id")
+	plan := newCatalogPlan("small-guid", "small", "small-id", mysql, "2.0.0")
+	db1 := newUpgradeableInstance("db1-guid", "db1", plan, true)
+	db2 := newUpgradeableInstance("db2-guid", "db2", plan, true)
+	db3 := newUpgradeableInstance("db3-guid", "db3", plan, false)
+	db1Upgraded := newUpgradeableInstance("db1-guid", "db1", plan, false)
+	db1Upgraded.LastOperation = resource.LastOperation{Type: "update", State: resource.LastOperationSucceeded}
+	db2Failed := newUpgradeableInstance("db2-guid", "db2", plan, true)
+	db2Failed.LastOperation = resource.LastOperation{Type: "update", State: resource.LastOperationFailed, Description: "upgrade not supported"}
+
+	setup := func(routes ...testutil.MockRoute) *client.Client {
+		serverURL := testutil.SetupMultiple(append([]testutil.MockRoute{
+			{
+				Method:      http.MethodGet,
+				Endpoint:    "/v3/service_plans",
+				Output:      g.SinglePaged(toJSON(t, plan)),
+				Status:      http.StatusOK,
+				QueryString: "names=small&page=1&per_page=50&service_offering_names=mysql",
+			},
+			{
+				Method:      http.MethodGet,
+				Endpoint:    "/v3/service_instances",
+				Output:      g.Paged([]string{toJSON(t, db1), toJSON(t, db2), toJSON(t, db3)}),
+				Status:      http.StatusOK,
+				QueryString: "label_selector=env=prod&organization_guids=org-guid&page=1&per_page=50&service_plan_guids=small-guid&type=managed",
+			},
+		}, routes...), t)
+		c, _ := config.NewToken(serverURL, "foobar")
+		cf, err := client.New(c)
+		require.NoError(t, err)
+		return cf
+	}
+	upgradeRoutes := func(instance *resource.ServiceInstance, after *resource.ServiceInstance) []testutil.MockRoute {
+		return []testutil.MockRoute{
+			{
+				Method:           http.MethodPatch,
+				Endpoint:         "/v3/service_instances/" + instance.GUID,
+				Status:           http.StatusAccepted,
+				RedirectLocation: "https://api.example.org/v3/jobs/" + job.GUID,
+				PostForm:         `{ "maintenance_info": { "version": "2.0.0" } }`,
+			},
+			{
+				Method:   http.MethodGet,
+				Endpoint: "/v3/service_instances/" + instance.GUID,
+				Output:   []string{toJSON(t, after), toJSON(t, after)},
+				Status:   http.StatusOK,
+			},
+		}
+	}
+	jobRoute := testutil.MockRoute{
+		Method:   http.MethodGet,
+		Endpoint: "/v3/jobs/" + job.GUID,
+		Output:   []string{job.JSON, job.JSON},
+		Status:   http.StatusOK,
+	}
+	filter := operation.ServiceInstanceUpgradeFilter{
+		ServiceOfferingNames: []string{"mysql"},
+		ServicePlanNames:     []string{"small"},
+		OrganizationGUIDs:    []string{"org-guid"},
+		LabelSelector:        "env=prod",
+	}
+	opts := client.NewPollingOptions()
+	opts.CheckInterval = time.Millisecond
+
+	t.Run("dry run", func(t *testing.T) {
+		defer testutil.Teardown()
+		cf := setup()
+		report, err := operation.NewServiceInstanceUpgradeOperation(cf).
+			WithDryRun(true).
+			Upgrade(context.Background(), filter, nil)
+		require.NoError(t, err)
+		require.True(t, report.DryRun)
+		pending := report.Results(operation.ServiceInstanceUpgradePending)
+		require.Len(t, pending, 2)
+		require.Equal(t, "db1", pending[0].Name)
+		require.Equal(t, "1.0.0", pending[0].FromVersion)
+		require.Equal(t, "2.0.0", pending[0].ToVersion)
+		require.Equal(t, "small", pending[0].PlanName)
+	})
+
+	var saved []byte
+	t.Run("upgrade records failures", func(t *testing.T) {
+		defer testutil.Teardown()
+		routes := append(upgradeRoutes(db1, db1Upgraded), upgradeRoutes(db2, db2Failed)...)
+		cf := setup(append(routes, jobRoute)...)
+
+		var mutex sync.Mutex
+		var progress []string
+		report, err := operation.NewServiceInstanceUpgradeOperation(cf).
+			WithConcurrency(2).
+			WithPollingOptions(opts).
+			WithProgress(func(r *operation.ServiceInstanceUpgradeResult) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				progress = append(progress, r.Name)
+			}).
+			Upgrade(context.Background(), filter, nil)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"db1", "db2"}, progress)
+
+		upgraded := report.Results(operation.ServiceInstanceUpgradeUpgraded)
+		require.Len(t, upgraded, 1)
+		require.Equal(t, "db1", upgraded[0].Name)
+		failed := report.Results(operation.ServiceInstanceUpgradeFailed)
+		require.Len(t, failed, 1)
+		require.Equal(t, "db2", failed[0].Name)
+		require.Contains(t, failed[0].Error, "upgrade not supported")
+
+		saved, err = json.Marshal(report)
+		require.NoError(t, err)
+	})
+
+	t.Run("resume skips upgraded instances", func(t *testing.T) {
+		defer testutil.Teardown()
+		var resume operation.ServiceInstanceUpgradeReport
+		require.NoError(t, json.Unmarshal(saved, &resume))
+
+		// db1 is still listed as upgradeable, but there's no route to upgrade it again
+		db2Upgraded := newUpgradeableInstance("db2-guid", "db2", plan, false)
+		db2Upgraded.LastOperation = resource.LastOperation{Type: "update", State: resource.LastOperationSucceeded}
+		cf := setup(append(upgradeRoutes(db2, db2Upgraded), jobRoute)...)
+
+		report, err := operation.NewServiceInstanceUpgradeOperation(cf).
+			WithPollingOptions(opts).
+			Upgrade(context.Background(), filter, &resume)
+		require.NoError(t, err)
+		require.Len(t, report.Results(operation.ServiceInstanceUpgradeUpgraded), 2)
+		require.Empty(t, report.Results(operation.ServiceInstanceUpgradeFailed))
+	})
+
+	t.Run("resume skips failed instances that are no longer upgradeable", func(t *testing.T) {
+		defer testutil.Teardown()
+		var resume operation.ServiceInstanceUpgradeReport
+		require.NoError(t, json.Unmarshal(saved, &resume))
+
+		// db2 failed in the saved report but has since been upgraded by hand
+		db2Upgraded := newUpgradeableInstance("db2-guid", "db2", plan, false)
+		serverURL := testutil.SetupMultiple([]testutil.MockRoute{
+			{
+				Method:      http.MethodGet,
+				Endpoint:    "/v3/service_plans",
+				Output:      g.SinglePaged(toJSON(t, plan)),
+				Status:      http.StatusOK,
+				QueryString: "names=small&page=1&per_page=50&service_offering_names=mysql",
+			},
+			{
+				Method:      http.MethodGet,
+				Endpoint:    "/v3/service_instances",
+				Output:      g.Paged([]string{toJSON(t, db1Upgraded), toJSON(t, db2Upgraded), toJSON(t, db3)}),
+				Status:      http.StatusOK,
+				QueryString: "label_selector=env=prod&organization_guids=org-guid&page=1&per_page=50&service_plan_guids=small-guid&type=managed",
+			},
+		}, t)
+		c, _ := config.NewToken(serverURL, "foobar")
+		cf, err := client.New(c)
+		require.NoError(t, err)
+
+		report, err := operation.NewServiceInstanceUpgradeOperation(cf).
+			WithPollingOptions(opts).
+			Upgrade(context.Background(), filter, &resume)
+		require.NoError(t, err)
+		upgraded := report.Results(operation.ServiceInstanceUpgradeUpgraded)
+		require.Len(t, upgraded, 1)
+		require.Equal(t, "db1", upgraded[0].Name)
+		require.Empty(t, report.Results(operation.ServiceInstanceUpgradeFailed))
+		skipped := report.Results(operation.ServiceInstanceUpgradeSkipped)
+		require.Len(t, skipped, 1)
+		require.Equal(t, "db2", skipped[0].Name)
+		require.Empty(t, skipped[0].Error)
+	})
+}
+
+func newUpgradeableInstance(guid, name string, plan *resource.ServicePlan, upgradeAvailable bool) *resource.ServiceInstance {
+	return &resource.ServiceInstance{
+		GUID: guid,
+		Name: name,
+		Type: "managed",
+		MaintenanceInfo: &resource.ServiceInstanceMaintenanceInfo{
+			Version: "1.0.0",
+		},
+		UpgradeAvailable: &upgradeAvailable,
+		Relationships: resource.ServiceInstanceRelationships{
+			ServicePlan: &resource.ToOneRelationship{
+				Data: &resource.Relationship{GUID: plan.GUID},
+			},
+			Space: &resource.ToOneRelationship{
+				Data: &resource.Relationship{GUID: "space-guid"},
+			},
+		},
+	}
+}
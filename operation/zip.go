@@ -0,0 +1,243 @@
+package operation
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ZipDirectory streams a zip archive of the app files in the directory suitable for uploading as a package
+//
+// Files excluded by the directory's .cfignore file and the CF CLI default excludes (.git, .cfignore, manifest.yml
+// etc) are skipped. Executable files keep their executable bit and all other files are made readable. Symlinks are
+// stored as symlinks and never followed, a symlink that points outside the directory is an error. The archive is
+// written as it's read, so the returned reader must be read to the end or closed.
+func ZipDirectory(dir string) (io.ReadCloser, error) {
+	appDir, err := openAppDirectory(dir)
+	if err != nil {
+		return nil, err
+	}
+	return appDir.zip(nil), nil
+}
+
+// appDirectory is a directory of app source files along with its .cfignore rules
+type appDirectory struct {
+	root   string
+	ignore *cfIgnore
+}
+
+// appFile is a file, directory or symlink in an app directory that isn't ignored
+type appFile struct {
+	name string // slash separated path relative to the app directory
+	path string
+	info fs.FileInfo
+}
+
+func openAppDirectory(dir string) (*appDirectory, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving app directory %s: %w", dir, err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("error reading app directory %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("expected %s to be a directory", dir)
+	}
+	ignore, err := readCFIgnore(root)
+	if err != nil {
+		return nil, err
+	}
+	return &appDirectory{
+		root:   root,
+		ignore: ignore,
+	}, nil
+}
+
+func readCFIgnore(root string) (*cfIgnore, error) {
+	contents, err := os.ReadFile(filepath.Join(root, ".cfignore"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return newCFIgnore(""), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading .cfignore: %w", err)
+	}
+	return newCFIgnore(string(contents)), nil
+}
+
+// fingerprint returns the SHA1 checksum, size and mode of each regular file for resource matching along with the
+// total number of entries, including directories and symlinks, that would be zipped
+func (d *appDirectory) fingerprint() ([]resource.ResourceMatch, int, error) {
+	var resources []resource.ResourceMatch
+	entries := 0
+	err := d.walk(func(f appFile) error {
+		mode := f.info.Mode()
+		if mode.IsDir() || mode&os.ModeSymlink != 0 {
+			entries++
+		}
+		if !mode.IsRegular() {
+			return nil
+		}
+		checksum, err := sha1File(f.path)
+		if err != nil {
+			return err
+		}
+		entries++
+		resources = append(resources, resource.ResourceMatch{
+			Checksum:    resource.ResourceMatchChecksum{Value: checksum},
+			SizeInBytes: int(f.info.Size()),
+			Path:        f.name,
+			Mode:        strconv.FormatUint(uint64(zipFileMode(f.info)), 8),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fingerprinting app directory %s: %w", d.root, err)
+	}
+	return resources, entries, nil
+}
+
+// walk calls fn for each file, directory and symlink in the app directory that isn't ignored
+func (d *appDirectory) walk(fn func(f appFile) error) error {
+	return filepath.WalkDir(d.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == d.root {
+			return nil
+		}
+		rel, err := filepath.Rel(d.root, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if d.ignore.ShouldIgnore(name) {
+			// a negated pattern could re-include something beneath an ignored directory
+			if entry.IsDir() && !d.ignore.hasNegation {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return fn(appFile{name: name, path: path, info: info})
+	})
+}
+
+// zip streams a zip archive of the app directory, files whose names are in skip are left out
+func (d *appDirectory) zip(skip map[string]bool) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(d.writeZip(pw, skip))
+	}()
+	return pr
+}
+
+func (d *appDirectory) writeZip(w io.Writer, skip map[string]bool) error {
+	zw := zip.NewWriter(w)
+	err := d.walk(func(f appFile) error {
+		switch {
+		case f.info.Mode()&os.ModeSymlink != 0:
+			return writeZipSymlink(zw, d.root, f)
+		case f.info.IsDir():
+			_, err := zw.CreateHeader(newZipHeader(f.name+"/", fs.ModeDir|0755))
+			return err
+		case f.info.Mode().IsRegular():
+			if skip[f.name] {
+				return nil
+			}
+			return writeZipFile(zw, f)
+		default:
+			// sockets, devices and named pipes can't be part of an app package
+			return nil
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("error zipping app directory %s: %w", d.root, err)
+	}
+	return zw.Close()
+}
+
+func writeZipFile(zw *zip.Writer, f appFile) error {
+	header := newZipHeader(f.name, zipFileMode(f.info))
+	header.Method = zip.Deflate
+	fw, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	_, err = io.Copy(fw, file)
+	return err
+}
+
+func writeZipSymlink(zw *zip.Writer, root string, f appFile) error {
+	target, err := os.Readlink(f.path)
+	if err != nil {
+		return err
+	}
+	if filepath.IsAbs(target) {
+		return fmt.Errorf("symlink %s points to absolute path %s, only relative symlinks within the app directory are supported", f.name, target)
+	}
+	resolved, err := filepath.Rel(root, filepath.Join(filepath.Dir(f.path), target))
+	if err != nil || resolved == ".." || strings.HasPrefix(resolved, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("symlink %s points to %s which is outside of the app directory", f.name, target)
+	}
+
+	fw, err := zw.CreateHeader(newZipHeader(f.name, fs.ModeSymlink|0777))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(fw, filepath.ToSlash(target))
+	return err
+}
+
+func newZipHeader(name string, mode fs.FileMode) *zip.FileHeader {
+	header := &zip.FileHeader{
+		Name:   name,
+		Method: zip.Store,
+	}
+	header.SetMode(mode)
+	return header
+}
+
+// zipFileMode returns the mode a regular file is packaged with, executable files stay executable
+func zipFileMode(info fs.FileInfo) fs.FileMode {
+	if info.Mode()&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+func sha1File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,141 @@
+package operation
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestZipDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "app.js", "console.log('hi')", 0600)
+	writeTestFile(t, dir, "bin/run.sh", "#!/bin/sh", 0700)
+	writeTestFile(t, dir, "logs/app.log", "log", 0644)
+	writeTestFile(t, dir, "manifest.yml", "applications: []", 0644)
+	writeTestFile(t, dir, ".git/HEAD", "ref", 0644)
+	writeTestFile(t, dir, ".cfignore", "logs/\n", 0644)
+	require.NoError(t, os.Symlink("bin/run.sh", filepath.Join(dir, "start")))
+	require.NoError(t, os.Symlink("bin", filepath.Join(dir, "scripts")))
+
+	files := readTestZip(t, dir)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{"app.js", "bin/", "bin/run.sh", "scripts", "start"}, names)
+
+	require.Equal(t, fs.FileMode(0644), files["app.js"].Mode())
+	require.Equal(t, fs.FileMode(0755), files["bin/run.sh"].Mode())
+	require.True(t, files["bin/"].Mode().IsDir())
+	require.Equal(t, fs.ModeSymlink, files["start"].Mode()&fs.ModeSymlink)
+	require.Equal(t, "bin/run.sh", readTestZipFile(t, files["start"]))
+	require.Equal(t, "bin", readTestZipFile(t, files["scripts"]))
+	require.Equal(t, "console.log('hi')", readTestZipFile(t, files["app.js"]))
+}
+
+func TestZipDirectoryErrors(t *testing.T) {
+	_, err := ZipDirectory(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+
+	dir := t.TempDir()
+	writeTestFile(t, dir, "app.js", "", 0644)
+	_, err = ZipDirectory(filepath.Join(dir, "app.js"))
+	require.Error(t, err)
+
+	dir = t.TempDir()
+	require.NoError(t, os.Symlink("../../etc/passwd", filepath.Join(dir, "passwd")))
+	r, err := ZipDirectory(dir)
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.ErrorContains(t, err, "outside of the app directory")
+
+	dir = t.TempDir()
+	require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(dir, "passwd")))
+	r, err = ZipDirectory(dir)
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.ErrorContains(t, err, "absolute path")
+}
+
+func TestAppDirectoryFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "app.js", "abc", 0600)
+	writeTestFile(t, dir, "bin/run.sh", "#!/bin/sh", 0700)
+	writeTestFile(t, dir, "logs/app.log", "log", 0644)
+	writeTestFile(t, dir, ".cfignore", "logs/\n", 0644)
+	require.NoError(t, os.Symlink("bin/run.sh", filepath.Join(dir, "start")))
+
+	appDir, err := openAppDirectory(dir)
+	require.NoError(t, err)
+	resources, entries, err := appDir.fingerprint()
+	require.NoError(t, err)
+	require.Equal(t, 4, entries)
+	require.Equal(t, []resource.ResourceMatch{
+		{
+			Checksum:    resource.ResourceMatchChecksum{Value: "a9993e364706816aba3e25717850c26c9cd0d89d"},
+			SizeInBytes: 3,
+			Path:        "app.js",
+			Mode:        "644",
+		},
+		{
+			Checksum:    resource.ResourceMatchChecksum{Value: "8ba27a5c52aadda081f0346f31b2e3044f15894c"},
+			SizeInBytes: 9,
+			Path:        "bin/run.sh",
+			Mode:        "755",
+		},
+	}, resources)
+
+	r := appDir.zip(map[string]bool{"app.js": true})
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"bin/", "bin/run.sh", "start"}, names)
+}
+
+func writeTestFile(t *testing.T, dir, name, contents string, mode fs.FileMode) {
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+	require.NoError(t, os.WriteFile(p, []byte(contents), mode))
+	require.NoError(t, os.Chmod(p, mode))
+}
+
+func readTestZip(t *testing.T, dir string) map[string]*zip.File {
+	r, err := ZipDirectory(dir)
+	require.NoError(t, err)
+	defer func() {
+		_ = r.Close()
+	}()
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	return files
+}
+
+func readTestZipFile(t *testing.T, f *zip.File) string {
+	rc, err := f.Open()
+	require.NoError(t, err)
+	defer func() {
+		_ = rc.Close()
+	}()
+	b, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return string(b)
+}
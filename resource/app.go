@@ -91,6 +91,7 @@ const (
 	LifecycleNone LifecycleType = iota
 	LifecycleBuildpack
 	LifecycleDocker
+	LifecycleCNB
 )
 
 func (l LifecycleType) String() string {
@@ -99,6 +100,8 @@ func (l LifecycleType) String() string {
 		return "buildpack"
 	case LifecycleDocker:
 		return "docker"
+	case LifecycleCNB:
+		return "cnb"
 	}
 	return ""
 }
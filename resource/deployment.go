@@ -2,10 +2,40 @@ package resource
 
 import "time"
 
+type DeploymentStrategy string
+
+// The supported deployment strategies
+const (
+	DeploymentStrategyRolling DeploymentStrategy = "rolling"
+	DeploymentStrategyCanary  DeploymentStrategy = "canary"
+)
+
+type DeploymentStatusValue string
+
+// The 2 deployment status values
+const (
+	DeploymentStatusValueActive    DeploymentStatusValue = "ACTIVE"
+	DeploymentStatusValueFinalized DeploymentStatusValue = "FINALIZED"
+)
+
+type DeploymentStatusReason string
+
+// The deployment status reasons, DEPLOYING, PAUSED and CANCELING are ACTIVE reasons while the rest are FINALIZED
+const (
+	DeploymentStatusReasonDeploying  DeploymentStatusReason = "DEPLOYING"
+	DeploymentStatusReasonPaused     DeploymentStatusReason = "PAUSED"
+	DeploymentStatusReasonCanceling  DeploymentStatusReason = "CANCELING"
+	DeploymentStatusReasonDeployed   DeploymentStatusReason = "DEPLOYED"
+	DeploymentStatusReasonCanceled   DeploymentStatusReason = "CANCELED"
+	DeploymentStatusReasonSuperseded DeploymentStatusReason = "SUPERSEDED"
+	DeploymentStatusReasonDegenerate DeploymentStatusReason = "DEGENERATE"
+)
+
 type Deployment struct {
 	GUID            string             `json:"guid"`
 	Status          DeploymentStatus   `json:"status"`
-	Strategy        string             `json:"strategy"`
+	Strategy        DeploymentStrategy `json:"strategy"`
+	Options         *DeploymentOptions `json:"options,omitempty"`
 	Droplet         Relationship       `json:"droplet"`
 	PreviousDroplet Relationship       `json:"previous_droplet"`
 	NewProcesses    []ProcessReference `json:"new_processes"`
@@ -21,10 +51,24 @@ type DeploymentCreate struct {
 	Relationships AppRelationship     `json:"relationships"`
 	Droplet       *Relationship       `json:"droplet,omitempty"`
 	Revision      *DeploymentRevision `json:"revision,omitempty"`
-	Strategy      string              `json:"strategy,omitempty"`
+	Strategy      DeploymentStrategy  `json:"strategy,omitempty"`
+	Options       *DeploymentOptions  `json:"options,omitempty"`
 	Metadata      *Metadata           `json:"metadata,omitempty"`
 }
 
+type DeploymentOptions struct {
+	MaxInFlight *int                     `json:"max_in_flight,omitempty"` // Max number of new instances to deploy simultaneously
+	Canary      *DeploymentCanaryOptions `json:"canary,omitempty"`        // Only valid for the canary strategy
+}
+
+type DeploymentCanaryOptions struct {
+	Steps []DeploymentCanaryStep `json:"steps,omitempty"`
+}
+
+type DeploymentCanaryStep struct {
+	InstanceWeight int `json:"instance_weight"` // Percentage of instances to run the new droplet, 1-100
+}
+
 type DeploymentUpdate struct {
 	Metadata *Metadata `json:"metadata"`
 }
@@ -45,9 +89,40 @@ type ProcessReference struct {
 }
 
 type DeploymentStatus struct {
-	Value   string            `json:"value"`
-	Reason  string            `json:"reason"`
-	Details map[string]string `json:"details"`
+	Value   DeploymentStatusValue   `json:"value"`
+	Reason  DeploymentStatusReason  `json:"reason"`
+	Details DeploymentStatusDetails `json:"details"`
+}
+
+type DeploymentStatusDetails struct {
+	LastSuccessfulHealthcheck string                        `json:"last_successful_healthcheck,omitempty"`
+	LastStatusChange          string                        `json:"last_status_change,omitempty"`
+	Error                     string                        `json:"error,omitempty"`
+	Canary                    *DeploymentCanaryStatusDetail `json:"canary,omitempty"`
+}
+
+type DeploymentCanaryStatusDetail struct {
+	Steps DeploymentCanaryStepsStatus `json:"steps"`
+}
+
+type DeploymentCanaryStepsStatus struct {
+	Current int `json:"current"`
+	Total   int `json:"total"`
+}
+
+// IsActive returns true if the deployment is still in progress
+func (s DeploymentStatus) IsActive() bool {
+	return s.Value == DeploymentStatusValueActive
+}
+
+// IsPaused returns true if the canary deployment is paused waiting to be continued
+func (s DeploymentStatus) IsPaused() bool {
+	return s.Value == DeploymentStatusValueActive && s.Reason == DeploymentStatusReasonPaused
+}
+
+// IsDeployed returns true if the deployment finished successfully
+func (s DeploymentStatus) IsDeployed() bool {
+	return s.Value == DeploymentStatusValueFinalized && s.Reason == DeploymentStatusReasonDeployed
 }
 
 func NewDeploymentCreate(appGUID string) *DeploymentCreate {
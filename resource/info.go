@@ -0,0 +1,36 @@
+package resource
+
+// Info provides information about the Cloud Foundry deployment
+type Info struct {
+	Build         string          `json:"build"`
+	CLIVersion    InfoCLIVersion  `json:"cli_version"`
+	Custom        map[string]any  `json:"custom"`
+	Description   string          `json:"description"`
+	Name          string          `json:"name"`
+	Version       int             `json:"version"`
+	OSBAPIVersion string          `json:"osbapi_version,omitempty"`
+	Links         map[string]Link `json:"links"`
+}
+
+// InfoCLIVersion is the CF CLI version constraints for the platform
+type InfoCLIVersion struct {
+	Minimum     string `json:"minimum"`
+	Recommended string `json:"recommended"`
+}
+
+// InfoUsageSummary provides platform-wide usage information
+type InfoUsageSummary struct {
+	UsageSummary PlatformUsageSummary `json:"usage_summary"`
+	Links        map[string]Link      `json:"links,omitempty"`
+}
+
+type PlatformUsageSummary struct {
+	StartedInstances int `json:"started_instances"`
+	MemoryInMb       int `json:"memory_in_mb"`
+	Routes           int `json:"routes"`
+	ServiceInstances int `json:"service_instances"`
+	ReservedPorts    int `json:"reserved_ports"`
+	Domains          int `json:"domains"`
+	PerAppTasks      int `json:"per_app_tasks"`
+	ServiceKeys      int `json:"service_keys"`
+}
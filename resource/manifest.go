@@ -1,12 +1,21 @@
 package resource
 
+type ManifestDiffOp string
+
+// The JSON-Patch style operations a manifest diff may contain
+const (
+	ManifestDiffOpAdd     ManifestDiffOp = "add"
+	ManifestDiffOpRemove  ManifestDiffOp = "remove"
+	ManifestDiffOpReplace ManifestDiffOp = "replace"
+)
+
 type ManifestDiff struct {
 	Diff []ManifestDiffItem `json:"diff"`
 }
 
 type ManifestDiffItem struct {
-	Op    string `json:"op"`
-	Path  string `json:"path"`
-	Was   string `json:"was,omitempty"`
-	Value string `json:"value,omitempty"`
+	Op    ManifestDiffOp `json:"op"`
+	Path  string         `json:"path"`            // JSON pointer to the changed manifest field, e.g. /applications/0/memory
+	Was   any            `json:"was,omitempty"`   // Previous value for remove and replace ops, can be any JSON type
+	Value any            `json:"value,omitempty"` // New value for add and replace ops, can be any JSON type
 }
@@ -0,0 +1,177 @@
+package resource
+
+import (
+	"sort"
+	"time"
+)
+
+// Process instance states as reported in ProcessStat.State
+const (
+	ProcessStateRunning  = "RUNNING"
+	ProcessStateCrashed  = "CRASHED"
+	ProcessStateStarting = "STARTING"
+	ProcessStateDown     = "DOWN"
+)
+
+// ProcessStatsSummary is an aggregate of the per-instance stats of one or more processes
+//
+// CPU, memory and disk values are only aggregated over running instances, as the usage of instances
+// in any other state is either zero or stale.
+type ProcessStatsSummary struct {
+	Instances int `json:"instances"`
+	Running   int `json:"running"`
+	Starting  int `json:"starting"`
+	Crashed   int `json:"crashed"`
+	Down      int `json:"down"`
+
+	// CPU usage of running instances, where 1.0 is one fully used core
+	AvgCPU float64 `json:"avg_cpu"`
+	MaxCPU float64 `json:"max_cpu"`
+
+	// Memory and disk usage of running instances as a percentage of their quota
+	AvgMemoryPercent float64 `json:"avg_memory_percent"`
+	MaxMemoryPercent float64 `json:"max_memory_percent"`
+	AvgDiskPercent   float64 `json:"avg_disk_percent"`
+	MaxDiskPercent   float64 `json:"max_disk_percent"`
+
+	// Uptime of the most and least recently started running instances
+	MinUptime time.Duration `json:"min_uptime"`
+	MaxUptime time.Duration `json:"max_uptime"`
+}
+
+// AppStats is a rolled up report of the stats of all of an app's processes
+type AppStats struct {
+	AppGUID string `json:"app_guid"`
+
+	// Summary aggregates the instances of all the app's processes
+	Summary ProcessStatsSummary `json:"summary"`
+
+	// Processes contains the stats and summary of each process keyed by process type, e.g. web
+	Processes map[string]*AppProcessStats `json:"processes"`
+}
+
+// AppProcessStats are the stats of a single process within an AppStats report
+type AppProcessStats struct {
+	ProcessGUID string              `json:"process_guid"`
+	Summary     ProcessStatsSummary `json:"summary"`
+	Stats       *ProcessStats       `json:"stats"`
+}
+
+// IsRunning returns true if the instance is running
+func (s ProcessStat) IsRunning() bool {
+	return s.State == ProcessStateRunning
+}
+
+// MemoryPercent returns the instance memory usage as a percentage of its quota, or 0 if there's no quota
+func (s ProcessStat) MemoryPercent() float64 {
+	return percent(s.Usage.Memory, s.MemoryQuota)
+}
+
+// DiskPercent returns the instance disk usage as a percentage of its quota, or 0 if there's no quota
+func (s ProcessStat) DiskPercent() float64 {
+	return percent(s.Usage.Disk, s.DiskQuota)
+}
+
+// UptimeDuration returns the instance uptime
+func (s ProcessStat) UptimeDuration() time.Duration {
+	return time.Duration(s.Uptime) * time.Second
+}
+
+// Running returns the stats of the running instances ordered by instance index
+func (s *ProcessStats) Running() []ProcessStat {
+	var running []ProcessStat
+	for _, stat := range s.Stats {
+		if stat.IsRunning() {
+			running = append(running, stat)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool {
+		return running[i].Index < running[j].Index
+	})
+	return running
+}
+
+// CountByState returns the number of instances in the specified state
+func (s *ProcessStats) CountByState(state string) int {
+	count := 0
+	for _, stat := range s.Stats {
+		if stat.State == state {
+			count++
+		}
+	}
+	return count
+}
+
+// Summary aggregates the instance stats
+func (s *ProcessStats) Summary() ProcessStatsSummary {
+	return SummarizeProcessStats(s)
+}
+
+// SummarizeProcessStats aggregates the instance stats of one or more processes
+func SummarizeProcessStats(stats ...*ProcessStats) ProcessStatsSummary {
+	var sum ProcessStatsSummary
+	var totalCPU, totalMemoryPercent, totalDiskPercent float64
+	for _, s := range stats {
+		if s == nil {
+			continue
+		}
+		for _, stat := range s.Stats {
+			sum.Instances++
+			switch stat.State {
+			case ProcessStateRunning:
+				sum.Running++
+			case ProcessStateStarting:
+				sum.Starting++
+			case ProcessStateCrashed:
+				sum.Crashed++
+			case ProcessStateDown:
+				sum.Down++
+			}
+			if !stat.IsRunning() {
+				continue
+			}
+
+			memoryPercent, diskPercent, uptime := stat.MemoryPercent(), stat.DiskPercent(), stat.UptimeDuration()
+			totalCPU += stat.Usage.CPU
+			totalMemoryPercent += memoryPercent
+			totalDiskPercent += diskPercent
+			if sum.Running == 1 {
+				sum.MaxCPU = stat.Usage.CPU
+				sum.MaxMemoryPercent = memoryPercent
+				sum.MaxDiskPercent = diskPercent
+				sum.MinUptime = uptime
+				sum.MaxUptime = uptime
+				continue
+			}
+			sum.MaxCPU = maxFloat(sum.MaxCPU, stat.Usage.CPU)
+			sum.MaxMemoryPercent = maxFloat(sum.MaxMemoryPercent, memoryPercent)
+			sum.MaxDiskPercent = maxFloat(sum.MaxDiskPercent, diskPercent)
+			if uptime < sum.MinUptime {
+				sum.MinUptime = uptime
+			}
+			if uptime > sum.MaxUptime {
+				sum.MaxUptime = uptime
+			}
+		}
+	}
+	if sum.Running > 0 {
+		sum.AvgCPU = totalCPU / float64(sum.Running)
+		sum.AvgMemoryPercent = totalMemoryPercent / float64(sum.Running)
+		sum.AvgDiskPercent = totalDiskPercent / float64(sum.Running)
+	}
+	return sum
+}
+
+func percent(used, quota int) float64 {
+	if quota <= 0 {
+		return 0
+	}
+	return float64(used) / float64(quota) * 100
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
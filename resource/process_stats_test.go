@@ -0,0 +1,62 @@
+package resource_test
+
+import (
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestProcessStatsSummary(t *testing.T) {
+	stats := &resource.ProcessStats{
+		Stats: []resource.ProcessStat{
+			{
+				Index:       2,
+				State:       resource.ProcessStateRunning,
+				Usage:       resource.Usage{CPU: 0.2, Memory: 512, Disk: 250},
+				Uptime:      30,
+				MemoryQuota: 1024,
+				DiskQuota:   1000,
+			},
+			{
+				Index:       0,
+				State:       resource.ProcessStateRunning,
+				Usage:       resource.Usage{CPU: 0.6, Memory: 256, Disk: 750},
+				Uptime:      3600,
+				MemoryQuota: 1024,
+				DiskQuota:   1000,
+			},
+			{Index: 1, State: resource.ProcessStateCrashed, MemoryQuota: 1024, DiskQuota: 1000},
+			{Index: 3, State: resource.ProcessStateDown},
+			{Index: 4, State: resource.ProcessStateStarting},
+		},
+	}
+
+	running := stats.Running()
+	require.Len(t, running, 2)
+	require.Equal(t, 0, running[0].Index)
+	require.Equal(t, 2, running[1].Index)
+	require.Equal(t, 25.0, running[0].MemoryPercent())
+	require.Equal(t, 75.0, running[0].DiskPercent())
+	require.Equal(t, time.Hour, running[0].UptimeDuration())
+	require.Equal(t, 0.0, stats.Stats[3].MemoryPercent())
+	require.Equal(t, 1, stats.CountByState(resource.ProcessStateCrashed))
+
+	sum := stats.Summary()
+	require.Equal(t, 5, sum.Instances)
+	require.Equal(t, 2, sum.Running)
+	require.Equal(t, 1, sum.Starting)
+	require.Equal(t, 1, sum.Crashed)
+	require.Equal(t, 1, sum.Down)
+	require.InDelta(t, 0.4, sum.AvgCPU, 0.0001)
+	require.Equal(t, 0.6, sum.MaxCPU)
+	require.Equal(t, 37.5, sum.AvgMemoryPercent)
+	require.Equal(t, 50.0, sum.MaxMemoryPercent)
+	require.Equal(t, 50.0, sum.AvgDiskPercent)
+	require.Equal(t, 75.0, sum.MaxDiskPercent)
+	require.Equal(t, 30*time.Second, sum.MinUptime)
+	require.Equal(t, time.Hour, sum.MaxUptime)
+
+	require.Equal(t, resource.ProcessStatsSummary{}, resource.SummarizeProcessStats())
+	require.Equal(t, resource.ProcessStatsSummary{}, resource.SummarizeProcessStats(nil))
+}
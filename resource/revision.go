@@ -30,10 +30,8 @@ type RevisionProcess struct {
 	Command string `json:"command"`
 }
 
-type RevisionProcesses struct {
-	Web    *RevisionProcess `json:"web,omitempty"`
-	Worker *RevisionProcess `json:"worker,omitempty"`
-}
+// RevisionProcesses are the revision's processes keyed by process type e.g. web
+type RevisionProcesses map[string]RevisionProcess
 
 type RevisionSidecar struct {
 	Name         string   `json:"name"`
@@ -25,7 +25,11 @@ type RootLinks struct {
 
 type RootCloudController struct {
 	Link
-	Meta Meta `json:"meta"`
+	Meta RootCloudControllerMeta `json:"meta"`
+}
+
+type RootCloudControllerMeta struct {
+	Version string `json:"version"`
 }
 
 type RootAppSSHMeta struct {
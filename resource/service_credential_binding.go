@@ -26,6 +26,12 @@ type ServiceCredentialBindingDetails struct {
 	VolumeMounts   []string       `json:"volume_mounts"`
 }
 
+// ServiceCredentialBindingWithDetails is a service credential binding along with its credentials
+type ServiceCredentialBindingWithDetails struct {
+	ServiceCredentialBinding
+	Details ServiceCredentialBindingDetails `json:"details"`
+}
+
 type ServiceCredentialBindingCreate struct {
 	Type          string                                `json:"type"`          // Type of the service credential binding. Valid values are key and app
 	Relationships ServiceCredentialBindingRelationships `json:"relationships"` // The service instance to be bound
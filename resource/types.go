@@ -57,6 +57,14 @@ type NullableRelationship struct {
 	GUID *string `json:"guid"`
 }
 
+// Last operation states
+const (
+	LastOperationInitial    = "initial"
+	LastOperationInProgress = "in progress"
+	LastOperationSucceeded  = "succeeded"
+	LastOperationFailed     = "failed"
+)
+
 type LastOperation struct {
 	Type        string    `json:"type"`
 	State       string    `json:"state"`
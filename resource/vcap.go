@@ -0,0 +1,202 @@
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+const (
+	// VCAPServicesEnvVar is the name of the environment variable containing the app's bound services
+	VCAPServicesEnvVar = "VCAP_SERVICES"
+
+	// VCAPApplicationEnvVar is the name of the environment variable containing the app's metadata
+	VCAPApplicationEnvVar = "VCAP_APPLICATION"
+
+	// redactedMessageKey is returned in place of the system env when the user can't read sensitive data
+	redactedMessageKey = "redacted_message"
+)
+
+// ErrVCAPRedacted is returned when the app's system environment was redacted because the user doesn't have
+// permission to read sensitive data
+var ErrVCAPRedacted = errors.New("the system environment was redacted, reading it requires permission to read sensitive app data")
+
+// VCAPServices are the services bound to an app grouped by service offering label
+type VCAPServices map[string][]VCAPService
+
+// VCAPService is a single service binding within VCAP_SERVICES
+type VCAPService struct {
+	Name           string            `json:"name"` // The binding name if set, otherwise the service instance name
+	BindingGUID    string            `json:"binding_id"`
+	BindingName    *string           `json:"binding_name"`
+	InstanceGUID   string            `json:"instance_id"`
+	InstanceName   string            `json:"instance_name"`
+	Label          string            `json:"label"` // The service offering name, or user-provided
+	Plan           string            `json:"plan"`
+	Provider       *string           `json:"provider"`
+	Tags           []string          `json:"tags"`
+	Credentials    map[string]any    `json:"credentials"`
+	SyslogDrainURL *string           `json:"syslog_drain_url"`
+	VolumeMounts   []VCAPVolumeMount `json:"volume_mounts"`
+}
+
+type VCAPVolumeMount struct {
+	ContainerDir string `json:"container_dir"`
+	DeviceType   string `json:"device_type"`
+	Mode         string `json:"mode"`
+}
+
+// VCAPApplication is the app metadata within VCAP_APPLICATION
+type VCAPApplication struct {
+	ApplicationID      string            `json:"application_id"`
+	ApplicationName    string            `json:"application_name"`
+	ApplicationURIs    []string          `json:"application_uris"`
+	ApplicationVersion string            `json:"application_version"`
+	CFAPI              string            `json:"cf_api"`
+	Host               string            `json:"host"`
+	InstanceID         string            `json:"instance_id"`
+	InstanceIndex      *int              `json:"instance_index"`
+	Limits             VCAPAppLimits     `json:"limits"`
+	Name               string            `json:"name"`
+	OrganizationID     string            `json:"organization_id"`
+	OrganizationName   string            `json:"organization_name"`
+	ProcessID          string            `json:"process_id"`
+	ProcessType        string            `json:"process_type"`
+	SpaceID            string            `json:"space_id"`
+	SpaceName          string            `json:"space_name"`
+	URIs               []string          `json:"uris"`
+	Port               *int              `json:"port"`
+	Start              string            `json:"start"`
+	StartedAt          string            `json:"started_at"`
+	StartedAtTimestamp int64             `json:"started_at_timestamp"`
+	StateTimestamp     int64             `json:"state_timestamp"`
+	Version            string            `json:"version"`
+	Users              map[string]string `json:"users"`
+}
+
+type VCAPAppLimits struct {
+	Disk int `json:"disk"` // Disk quota in MB
+	FDs  int `json:"fds"`  // File descriptor quota
+	Mem  int `json:"mem"`  // Memory quota in MB
+}
+
+// ParseVCAPServices parses the JSON contents of the VCAP_SERVICES environment variable
+func ParseVCAPServices(data []byte) (VCAPServices, error) {
+	services := VCAPServices{}
+	if len(data) == 0 {
+		return services, nil
+	}
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", VCAPServicesEnvVar, err)
+	}
+	return services, nil
+}
+
+// ParseVCAPApplication parses the JSON contents of the VCAP_APPLICATION environment variable
+func ParseVCAPApplication(data []byte) (*VCAPApplication, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("expected %s to be set", VCAPApplicationEnvVar)
+	}
+	var app VCAPApplication
+	if err := json.Unmarshal(data, &app); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", VCAPApplicationEnvVar, err)
+	}
+	return &app, nil
+}
+
+// VCAPServicesFromEnv parses the VCAP_SERVICES environment variable of the current process, this is intended
+// for apps running on CF
+func VCAPServicesFromEnv() (VCAPServices, error) {
+	return ParseVCAPServices([]byte(os.Getenv(VCAPServicesEnvVar)))
+}
+
+// VCAPApplicationFromEnv parses the VCAP_APPLICATION environment variable of the current process, this is
+// intended for apps running on CF
+func VCAPApplicationFromEnv() (*VCAPApplication, error) {
+	return ParseVCAPApplication([]byte(os.Getenv(VCAPApplicationEnvVar)))
+}
+
+// Services parses the VCAP_SERVICES system environment variable
+func (e *AppEnvironment) Services() (VCAPServices, error) {
+	if _, ok := e.SystemEnvVars[redactedMessageKey]; ok {
+		return nil, ErrVCAPRedacted
+	}
+	return ParseVCAPServices(e.SystemEnvVars[VCAPServicesEnvVar])
+}
+
+// Application parses the VCAP_APPLICATION application environment variable
+func (e *AppEnvironment) Application() (*VCAPApplication, error) {
+	if _, ok := e.AppEnvVars[redactedMessageKey]; ok {
+		return nil, ErrVCAPRedacted
+	}
+	return ParseVCAPApplication(e.AppEnvVars[VCAPApplicationEnvVar])
+}
+
+// Labels returns the sorted service offering labels of all the bound services
+func (s VCAPServices) Labels() []string {
+	labels := make([]string, 0, len(s))
+	for label := range s {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// All returns all the bound services ordered by label
+func (s VCAPServices) All() []VCAPService {
+	var all []VCAPService
+	for _, label := range s.Labels() {
+		all = append(all, s[label]...)
+	}
+	return all
+}
+
+// ByName returns the service with the specified binding name or, if there isn't one, instance name
+func (s VCAPServices) ByName(name string) (*VCAPService, error) {
+	all := s.All()
+	for i := range all {
+		if all[i].Name == name {
+			return &all[i], nil
+		}
+	}
+	for i := range all {
+		if all[i].InstanceName == name {
+			return &all[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no service named %s found in %s", name, VCAPServicesEnvVar)
+}
+
+// ByLabel returns all the services of the specified service offering label
+func (s VCAPServices) ByLabel(label string) []VCAPService {
+	return s[label]
+}
+
+// ByTag returns all the services that have the specified tag
+func (s VCAPServices) ByTag(tag string) []VCAPService {
+	var tagged []VCAPService
+	for _, svc := range s.All() {
+		if svc.HasTag(tag) {
+			tagged = append(tagged, svc)
+		}
+	}
+	return tagged
+}
+
+// HasTag returns true if the service has the specified tag
+func (s VCAPService) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// CredentialString returns the credential with the specified key if it exists and is a string
+func (s VCAPService) CredentialString(key string) (string, bool) {
+	v, ok := s.Credentials[key].(string)
+	return v, ok
+}
@@ -0,0 +1,148 @@
+package resource_test
+
+import (
+	"encoding/json"
+	"github.com/cloudfoundry-community/go-cfclient/v3/resource"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const appEnvironmentJSON = `{
+  "system_env_json": {
+    "VCAP_SERVICES": {
+      "mysql": [
+        {
+          "name": "primary-db",
+          "binding_id": "0e85b634-e043-4b43-96da-f83dfe83ab33",
+          "binding_name": "primary-db",
+          "instance_id": "07fca01c-f789-4d45-80b4-e19ba3ca862c",
+          "instance_name": "my-mysql-service",
+          "label": "mysql",
+          "tags": ["relational", "sql"],
+          "plan": "xlarge",
+          "credentials": {
+            "username": "user",
+            "password": "top-secret",
+            "port": 3306
+          },
+          "syslog_drain_url": null,
+          "volume_mounts": [],
+          "provider": null
+        }
+      ],
+      "user-provided": [
+        {
+          "name": "my-ups",
+          "binding_id": "bc5e8ff3-ac84-4b7d-a4e1-c5e6a1a3bd7b",
+          "binding_name": null,
+          "instance_id": "ebe7bb7e-5aa8-4e62-9a0e-1a4bb7f2e2a1",
+          "instance_name": "my-ups",
+          "label": "user-provided",
+          "tags": ["sql"],
+          "credentials": {
+            "uri": "https://ups.example.org"
+          },
+          "syslog_drain_url": "https://syslog.example.org/drain",
+          "volume_mounts": []
+        }
+      ]
+    }
+  },
+  "application_env_json": {
+    "VCAP_APPLICATION": {
+      "application_id": "1cb006ee-fb05-47e1-b541-c34179ddc446",
+      "application_name": "my-app",
+      "application_uris": ["my-app.example.org"],
+      "cf_api": "https://api.example.org",
+      "limits": {
+        "disk": 1024,
+        "fds": 16384,
+        "mem": 256
+      },
+      "name": "my-app",
+      "organization_id": "e1c2f2b4-6d63-4b9c-8d7e-7f2a2d6c1b11",
+      "organization_name": "my-org",
+      "space_id": "2f35885d-0c9d-4423-83ad-fd05066f8576",
+      "space_name": "my-space",
+      "uris": ["my-app.example.org"],
+      "users": null
+    }
+  }
+}`
+
+func TestAppEnvironmentVCAP(t *testing.T) {
+	var env resource.AppEnvironment
+	require.NoError(t, json.Unmarshal([]byte(appEnvironmentJSON), &env))
+
+	services, err := env.Services()
+	require.NoError(t, err)
+	require.Equal(t, []string{"mysql", "user-provided"}, services.Labels())
+	require.Len(t, services.All(), 2)
+
+	db, err := services.ByName("primary-db")
+	require.NoError(t, err)
+	require.Equal(t, "xlarge", db.Plan)
+	require.Equal(t, "primary-db", *db.BindingName)
+	require.Equal(t, "0e85b634-e043-4b43-96da-f83dfe83ab33", db.BindingGUID)
+	password, ok := db.CredentialString("password")
+	require.True(t, ok)
+	require.Equal(t, "top-secret", password)
+	_, ok = db.CredentialString("port")
+	require.False(t, ok)
+
+	ups, err := services.ByName("my-ups")
+	require.NoError(t, err)
+	require.Nil(t, ups.BindingName)
+	require.Equal(t, "https://syslog.example.org/drain", *ups.SyslogDrainURL)
+
+	_, err = services.ByName("missing")
+	require.Error(t, err)
+
+	require.Len(t, services.ByLabel("mysql"), 1)
+	require.Len(t, services.ByTag("sql"), 2)
+	require.Len(t, services.ByTag("relational"), 1)
+	require.Empty(t, services.ByTag("nosql"))
+
+	app, err := env.Application()
+	require.NoError(t, err)
+	require.Equal(t, "my-app", app.ApplicationName)
+	require.Equal(t, "my-space", app.SpaceName)
+	require.Equal(t, "https://api.example.org", app.CFAPI)
+	require.Equal(t, resource.VCAPAppLimits{Disk: 1024, FDs: 16384, Mem: 256}, app.Limits)
+}
+
+func TestAppEnvironmentVCAPRedacted(t *testing.T) {
+	var env resource.AppEnvironment
+	err := json.Unmarshal([]byte(`{
+		"system_env_json": { "redacted_message": "[PRIVATE DATA HIDDEN]" },
+		"application_env_json": { "redacted_message": "[PRIVATE DATA HIDDEN]" }
+	}`), &env)
+	require.NoError(t, err)
+
+	_, err = env.Services()
+	require.ErrorIs(t, err, resource.ErrVCAPRedacted)
+	_, err = env.Application()
+	require.ErrorIs(t, err, resource.ErrVCAPRedacted)
+}
+
+func TestVCAPFromEnv(t *testing.T) {
+	t.Setenv(resource.VCAPServicesEnvVar, "")
+	t.Setenv(resource.VCAPApplicationEnvVar, `{"application_name":"my-app","instance_index":2}`)
+
+	services, err := resource.VCAPServicesFromEnv()
+	require.NoError(t, err)
+	require.Empty(t, services)
+
+	app, err := resource.VCAPApplicationFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, "my-app", app.ApplicationName)
+	require.Equal(t, 2, *app.InstanceIndex)
+
+	t.Setenv(resource.VCAPServicesEnvVar, "{")
+	_, err = resource.VCAPServicesFromEnv()
+	require.Error(t, err)
+
+	t.Setenv(resource.VCAPApplicationEnvVar, "")
+	_, err = resource.VCAPApplicationFromEnv()
+	require.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"encoding/json"
 	"github.com/cloudfoundry-community/go-cfclient/v3/config"
 	"github.com/stretchr/testify/require"
 	"io"
@@ -30,6 +31,15 @@ type MockRoute struct {
 	QueryString      string
 	PostForm         string
 	RedirectLocation string
+
+	// Statuses optionally overrides Status for each sequential GET Output
+	Statuses []int
+
+	// MultipartFields are the expected non-file form fields of a POST-FILE request, JSON values are compared as JSON
+	MultipartFields map[string]string
+
+	// MultipartFiles are the expected file form field names of a POST-FILE request
+	MultipartFiles []string
 }
 
 func SetupFakeAPIServer() string {
@@ -86,6 +96,9 @@ func SetupMultiple(mockEndpoints []MockRoute, t *testing.T) string {
 		queryString := mock.QueryString
 		postFormBody := mock.PostForm
 		redirectLocation := mock.RedirectLocation
+		statuses := mock.Statuses
+		multipartFields := mock.MultipartFields
+		multipartFiles := mock.MultipartFiles
 		switch method {
 		case "GET":
 			count := 0
@@ -96,8 +109,12 @@ func SetupMultiple(mockEndpoints []MockRoute, t *testing.T) string {
 					res.Header().Add("Location", redirectLocation)
 				}
 				singleOutput := output[count]
+				singleStatus := status
+				if count < len(statuses) {
+					singleStatus = statuses[count]
+				}
 				count++
-				return status, singleOutput
+				return singleStatus, singleOutput
 			})
 		case "POST":
 			r.Post(endpoint, func(res http.ResponseWriter, req *http.Request) (int, string) {
@@ -138,6 +155,15 @@ func SetupMultiple(mockEndpoints []MockRoute, t *testing.T) string {
 				}
 				return status, output[0]
 			})
+		case "POST-FILE":
+			r.Post(endpoint, func(res http.ResponseWriter, req *http.Request) (int, string) {
+				testUserAgent(req.Header.Get("User-Agent"), userAgent, t)
+				testMultipartForm(req, multipartFields, multipartFiles, t)
+				if redirectLocation != "" {
+					res.Header().Add("Location", redirectLocation)
+				}
+				return status, output[0]
+			})
 		case "PUT-FILE":
 			r.Put(endpoint, func(res http.ResponseWriter, req *http.Request) (int, string) {
 				testUserAgent(req.Header.Get("User-Agent"), userAgent, t)
@@ -161,7 +187,7 @@ func SetupMultiple(mockEndpoints []MockRoute, t *testing.T) string {
 				"cloud_controller_v3": map[string]any{
 					"href": server.URL + "/v3",
 					"meta": map[string]any{
-						"version": "3.90.0",
+						"version": "3.180.0",
 					},
 				},
 				"network_policy_v0": map[string]any{
@@ -271,3 +297,40 @@ func testBodyContains(req *http.Request, expected string, t *testing.T) {
 		}
 	}
 }
+
+func testMultipartForm(req *http.Request, expectedFields map[string]string, expectedFiles []string, t *testing.T) {
+	t.Helper()
+	if err := req.ParseMultipartForm(32 << 20); err != nil {
+		t.Errorf("Expected a multipart form request body: %s", err)
+		return
+	}
+	defer func() {
+		_ = req.MultipartForm.RemoveAll()
+	}()
+
+	fields := make(map[string]string)
+	for name, values := range req.MultipartForm.Value {
+		fields[name] = values[0]
+	}
+	if len(fields) != len(expectedFields) {
+		t.Errorf("Expected multipart form fields %v but got %v", expectedFields, fields)
+	}
+	for name, expected := range expectedFields {
+		actual, ok := fields[name]
+		if !ok {
+			t.Errorf("Expected multipart form field %s was not found", name)
+			continue
+		}
+		if json.Valid([]byte(expected)) {
+			require.JSONEq(t, expected, actual, "Unexpected multipart form field %s", name)
+		} else {
+			require.Equal(t, expected, actual, "Unexpected multipart form field %s", name)
+		}
+	}
+
+	var files []string
+	for name := range req.MultipartForm.File {
+		files = append(files, name)
+	}
+	require.ElementsMatch(t, expectedFiles, files, "Unexpected multipart form files")
+}
@@ -167,8 +167,16 @@ func (o ObjectJSONGenerator) DropletAssociation() *JSONResource {
 }
 
 func (o ObjectJSONGenerator) Deployment() *JSONResource {
+	return o.DeploymentWithStatus("ACTIVE", "DEPLOYING")
+}
+
+func (o ObjectJSONGenerator) DeploymentWithStatus(value, reason string) *JSONResource {
 	r := &JSONResource{
 		GUID: RandomGUID(),
+		Params: map[string]string{
+			"value":  value,
+			"reason": reason,
+		},
 	}
 	return o.renderTemplate(r, "deployment.json")
 }
@@ -197,6 +205,18 @@ func (o ObjectJSONGenerator) FeatureFlag() *JSONResource {
 	return o.renderTemplate(r, "feature_flag.json")
 }
 
+func (o ObjectJSONGenerator) Info() *JSONResource {
+	r := &JSONResource{
+		Name: RandomName(),
+	}
+	return o.renderTemplate(r, "info.json")
+}
+
+func (o ObjectJSONGenerator) InfoUsageSummary() *JSONResource {
+	r := &JSONResource{}
+	return o.renderTemplate(r, "info_usage_summary.json")
+}
+
 func (o ObjectJSONGenerator) IsolationSegment() *JSONResource {
 	r := &JSONResource{
 		GUID: RandomGUID(),
@@ -228,7 +248,7 @@ func (o ObjectJSONGenerator) Manifest() *JSONResource {
 
 func (o ObjectJSONGenerator) ManifestDiff() *JSONResource {
 	r := &JSONResource{}
-	return o.renderTemplate(r, "manifest_diff.yml")
+	return o.renderTemplate(r, "manifest_diff.json")
 }
 
 func (o ObjectJSONGenerator) Organization() *JSONResource {
@@ -269,8 +289,15 @@ func (o ObjectJSONGenerator) PackageDocker() *JSONResource {
 }
 
 func (o ObjectJSONGenerator) Process() *JSONResource {
+	return o.ProcessOfType("web")
+}
+
+func (o ObjectJSONGenerator) ProcessOfType(processType string) *JSONResource {
 	r := &JSONResource{
 		GUID: RandomGUID(),
+		Params: map[string]string{
+			"type": processType,
+		},
 	}
 	return o.renderTemplate(r, "process.json")
 }
@@ -338,9 +365,18 @@ func (o ObjectJSONGenerator) SecurityGroup() *JSONResource {
 }
 
 func (o ObjectJSONGenerator) ServiceCredentialBinding() *JSONResource {
+	return o.ServiceCredentialBindingWithLastOperation("create", "succeeded", "Operation succeeded")
+}
+
+func (o ObjectJSONGenerator) ServiceCredentialBindingWithLastOperation(operationType, state, description string) *JSONResource {
 	r := &JSONResource{
 		GUID: RandomGUID(),
 		Name: RandomName(),
+		Params: map[string]string{
+			"type":        operationType,
+			"state":       state,
+			"description": description,
+		},
 	}
 	return o.renderTemplate(r, "service_credential_binding.json")
 }
@@ -351,9 +387,18 @@ func (o ObjectJSONGenerator) ServiceCredentialBindingDetails() *JSONResource {
 }
 
 func (o ObjectJSONGenerator) ServiceInstance() *JSONResource {
+	return o.ServiceInstanceWithLastOperation("create", "succeeded", "Operation succeeded")
+}
+
+func (o ObjectJSONGenerator) ServiceInstanceWithLastOperation(operationType, state, description string) *JSONResource {
 	r := &JSONResource{
 		GUID: RandomGUID(),
 		Name: RandomName(),
+		Params: map[string]string{
+			"type":        operationType,
+			"state":       state,
+			"description": description,
+		},
 	}
 	return o.renderTemplate(r, "service_instance.json")
 }